@@ -1,7 +1,9 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -18,11 +20,20 @@ import (
 	"github.com/gorilla/websocket"
 	"gradekeeper/internal/config"
 	"gradekeeper/internal/platform"
+	"gradekeeper/internal/receipt"
+	"gradekeeper/internal/session"
+	"gradekeeper/internal/watch"
 )
 
 const (
 	// Heartbeat configuration - should match server settings
 	HeartbeatInterval = 30 * time.Second
+
+	// How often the workspace is scanned for changes
+	WorkspacePollInterval = 2 * time.Second
+
+	// Maximum number of file events kept while the master is unreachable
+	maxPendingEvents = 1000
 )
 
 // ANSI color codes
@@ -95,8 +106,10 @@ type Message struct {
 }
 
 type Command struct {
-	Action string `json:"action"`
-	Target string `json:"target,omitempty"`
+	Action  string                 `json:"action"`
+	Target  string                 `json:"target,omitempty"`
+	Session string                 `json:"session,omitempty"`
+	Files   []config.WorkspaceFile `json:"files,omitempty"`
 }
 
 type Client struct {
@@ -110,6 +123,9 @@ type Client struct {
 	shouldNotReconnect bool
 	config             config.AppConfig
 	configMu           sync.RWMutex
+	writeMu            sync.Mutex // gorilla/websocket allows a single concurrent writer
+	pendingEvents      []watch.Event
+	pendingMu          sync.Mutex
 }
 
 func NewClient(serverURL string) *Client {
@@ -132,6 +148,13 @@ func (c *Client) connect() error {
 	header := make(map[string][]string)
 	header["X-Client-ID"] = []string{c.clientID}
 
+	// Register the receipt signing key with the master during the handshake
+	if key, err := c.signingKey(); err == nil {
+		header["X-Client-Key"] = []string{hex.EncodeToString(key.Public().(ed25519.PublicKey))}
+	} else {
+		logWarning("Signing key unavailable, receipts will be rejected: %v", err)
+	}
+
 	conn, _, err := websocket.DefaultDialer.Dial(u.String(), header)
 	if err != nil {
 		return fmt.Errorf("failed to connect to master: %v", err)
@@ -182,6 +205,8 @@ func (c *Client) connectWithRetry() {
 		c.retrying = false
 		c.sendStatus("connected")
 		c.requestConfig()
+		c.sendPendingReceipts()
+		c.sendPendingEvents()
 
 		// Start listening for messages
 		go c.listen()
@@ -192,6 +217,14 @@ func (c *Client) connectWithRetry() {
 	}
 }
 
+// writeJSON serializes writes to the connection, which is shared between the
+// message handler and the heartbeat goroutine.
+func (c *Client) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
 func (c *Client) requestConfig() {
 	if c.conn == nil {
 		return
@@ -202,7 +235,7 @@ func (c *Client) requestConfig() {
 		Timestamp: time.Now(),
 	}
 
-	if err := c.conn.WriteJSON(req); err != nil {
+	if err := c.writeJSON(req); err != nil {
 		logWarning("Failed to request config from master: %v", err)
 		return
 	}
@@ -258,17 +291,15 @@ func (c *Client) handleMessage(msg Message) {
 	case "error":
 		c.handleError(msg)
 	case "command":
-		if cmdData, ok := msg.Data.(map[string]interface{}); ok {
-			action := cmdData["action"].(string)
-			target := ""
-			if cmdData["target"] != nil {
-				target = cmdData["target"].(string)
-			}
+		cmd, err := decodeCommand(msg.Data)
+		if err != nil {
+			logWarning("Invalid command from master: %v", err)
+			return
+		}
 
-			// Check if command is for this client
-			if target == "all" || target == "" || target == c.clientID {
-				c.executeCommand(action)
-			}
+		// Check if command is for this client
+		if cmd.Target == "all" || cmd.Target == "" || cmd.Target == c.clientID {
+			c.executeCommand(cmd)
 		}
 	case "file_command":
 		c.handleFileCommand(msg)
@@ -277,6 +308,22 @@ func (c *Client) handleMessage(msg Message) {
 	}
 }
 
+func decodeCommand(data interface{}) (Command, error) {
+	var cmd Command
+
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return cmd, err
+	}
+	if err := json.Unmarshal(bytes, &cmd); err != nil {
+		return cmd, err
+	}
+	if cmd.Action == "" {
+		return cmd, fmt.Errorf("missing action")
+	}
+	return cmd, nil
+}
+
 func (c *Client) handleError(msg Message) {
 	if errorData, ok := msg.Data.(map[string]interface{}); ok {
 		errorType := errorData["error"].(string)
@@ -432,12 +479,13 @@ func (c *Client) sendFileResponse(action, filePath string, data interface{}, err
 		Timestamp: time.Now(),
 	}
 
-	if err := c.conn.WriteJSON(response); err != nil {
+	if err := c.writeJSON(response); err != nil {
 		logError("Failed to send file response: %v", err)
 	}
 }
 
-func (c *Client) executeCommand(action string) {
+func (c *Client) executeCommand(cmd Command) {
+	action := cmd.Action
 	logInfo("Executing command: %s", action)
 
 	// Send "started" status
@@ -448,7 +496,7 @@ func (c *Client) executeCommand(action string) {
 
 	switch action {
 	case "setup":
-		err = c.setupEnvironment()
+		err = c.setupEnvironment(cmd.Files)
 		result = map[string]interface{}{
 			"action": action,
 			"status": "completed",
@@ -482,6 +530,27 @@ func (c *Client) executeCommand(action string) {
 			"status": "completed",
 			"error":  errorToString(err),
 		}
+	case "freeze":
+		err = c.freezeAction(cmd.Session)
+		result = map[string]interface{}{
+			"action": action,
+			"status": "completed",
+			"error":  errorToString(err),
+		}
+	case "unfreeze":
+		err = c.unfreezeAction()
+		result = map[string]interface{}{
+			"action": action,
+			"status": "completed",
+			"error":  errorToString(err),
+		}
+	case "collect":
+		err = c.collectAction(cmd.Session)
+		result = map[string]interface{}{
+			"action": action,
+			"status": "completed",
+			"error":  errorToString(err),
+		}
 	default:
 		result = map[string]interface{}{
 			"action": action,
@@ -506,7 +575,7 @@ func (c *Client) executeCommand(action string) {
 	}
 }
 
-func (c *Client) setupEnvironment() error {
+func (c *Client) setupEnvironment(files []config.WorkspaceFile) error {
 	// Get Desktop path (cross-platform)
 	desktopPath, err := platform.GetDesktopPath()
 	if err != nil {
@@ -523,6 +592,28 @@ func (c *Client) setupEnvironment() error {
 	}
 
 	logSuccess("DOMJudge folder created successfully!")
+
+	// Seed template files without overwriting existing work
+	for _, file := range files {
+		if err := file.Validate(); err != nil {
+			return err
+		}
+
+		filePath := filepath.Join(domjudgePath, filepath.FromSlash(file.Path))
+		if _, err := os.Stat(filePath); err == nil {
+			logInfo("Template file already exists, keeping it: %s", file.Path)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return fmt.Errorf("error creating folder for %s: %v", file.Path, err)
+		}
+		if err := os.WriteFile(filePath, []byte(file.Content), 0644); err != nil {
+			return fmt.Errorf("error writing template file %s: %v", file.Path, err)
+		}
+		logSuccess("Template file created: %s", file.Path)
+	}
+
 	return nil
 }
 
@@ -576,7 +667,7 @@ func (c *Client) setupAllAction() error {
 
 	// Step 1: Setup environment (create DOMJudge folder)
 	logInfo("Creating DOMJudge folder...")
-	err := c.setupEnvironment()
+	err := c.setupEnvironment(nil)
 	if err != nil {
 		return fmt.Errorf("setup failed: %v", err)
 	}
@@ -613,6 +704,289 @@ func (c *Client) clearEnvironmentAction() error {
 	return nil
 }
 
+// freezeAction stops further edits at pencils-down: it closes editors, makes
+// the DOMJudge folder read-only and sends a signed manifest to the master.
+func (c *Client) freezeAction(sessionID string) error {
+	desktopPath, err := platform.GetDesktopPath()
+	if err != nil {
+		return fmt.Errorf("error getting desktop path: %v", err)
+	}
+
+	domjudgePath := filepath.Join(desktopPath, "DOMJudge")
+	if _, err := os.Stat(domjudgePath); err != nil {
+		return fmt.Errorf("DOMJudge folder not found: %v", err)
+	}
+
+	logInfo("Closing editors...")
+	if err := platform.CloseEditors(); err != nil {
+		logWarning("Failed to close editors: %v", err)
+	}
+
+	logInfo("Freezing %s", domjudgePath)
+	if err := platform.FreezeFolder(domjudgePath); err != nil {
+		return err
+	}
+
+	files, err := receipt.BuildManifest(domjudgePath)
+	if err != nil {
+		return fmt.Errorf("error building manifest: %v", err)
+	}
+
+	key, err := c.signingKey()
+	if err != nil {
+		return fmt.Errorf("error loading signing key: %v", err)
+	}
+
+	signed, err := receipt.Sign(receipt.Manifest{
+		ClientID:  c.clientID,
+		Session:   sessionID,
+		CreatedAt: time.Now(),
+		Files:     files,
+	}, key)
+	if err != nil {
+		return fmt.Errorf("error signing manifest: %v", err)
+	}
+
+	if err := c.sendReceipt(signed); err != nil {
+		// The workspace is already locked, so keep the receipt and deliver it
+		// once the master is reachable again
+		if queueErr := queueReceipt(signed); queueErr != nil {
+			return fmt.Errorf("error sending receipt: %v (queueing failed: %v)", err, queueErr)
+		}
+		logWarning("Workspace frozen, receipt queued until the master is reachable: %v", err)
+		return nil
+	}
+
+	logSuccess("Workspace frozen, receipt sent for %d files", len(files))
+	return nil
+}
+
+func (c *Client) sendReceipt(signed receipt.Receipt) error {
+	if c.conn == nil {
+		return fmt.Errorf("not connected to master")
+	}
+
+	msg := Message{
+		Type:      "receipt",
+		Data:      signed,
+		Timestamp: time.Now(),
+	}
+	return c.writeJSON(msg)
+}
+
+// pendingReceiptsDir holds signed receipts that could not be delivered.
+func pendingReceiptsDir() (string, error) {
+	stateDir, err := platform.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "pending-receipts"), nil
+}
+
+func queueReceipt(signed receipt.Receipt) error {
+	dir, err := pendingReceiptsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(signed)
+	if err != nil {
+		return err
+	}
+	name := time.Now().Format("20060102-150405.000000000") + ".json"
+	return os.WriteFile(filepath.Join(dir, name), data, 0600)
+}
+
+// sendPendingReceipts delivers receipts queued while the master was
+// unreachable, oldest first.
+func (c *Client) sendPendingReceipts() {
+	dir, err := pendingReceiptsDir()
+	if err != nil {
+		logWarning("Cannot check for queued receipts: %v", err)
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logWarning("Cannot read queued receipts: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logWarning("Cannot read queued receipt %s: %v", entry.Name(), err)
+			continue
+		}
+
+		var signed receipt.Receipt
+		if err := json.Unmarshal(data, &signed); err != nil {
+			logWarning("Discarding invalid queued receipt %s: %v", entry.Name(), err)
+			os.Remove(path)
+			continue
+		}
+
+		if err := c.sendReceipt(signed); err != nil {
+			logWarning("Failed to send queued receipt: %v", err)
+			return
+		}
+		os.Remove(path)
+		logSuccess("Sent queued receipt %s", entry.Name())
+	}
+}
+
+// unfreezeAction restores write access, e.g. for a student granted extra time.
+func (c *Client) unfreezeAction() error {
+	desktopPath, err := platform.GetDesktopPath()
+	if err != nil {
+		return fmt.Errorf("error getting desktop path: %v", err)
+	}
+
+	domjudgePath := filepath.Join(desktopPath, "DOMJudge")
+	if err := platform.UnfreezeFolder(domjudgePath); err != nil {
+		return err
+	}
+
+	logSuccess("Workspace unfrozen: %s", domjudgePath)
+	return nil
+}
+
+func (c *Client) signingKey() (ed25519.PrivateKey, error) {
+	stateDir, err := platform.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return receipt.LoadOrCreateKey(filepath.Join(stateDir, "client.key"))
+}
+
+// collectAction sends every file in the DOMJudge folder to the master so it
+// can be archived under the exam session.
+func (c *Client) collectAction(sessionID string) error {
+	if sessionID == "" {
+		return fmt.Errorf("collect requires a session")
+	}
+	if c.conn == nil {
+		return fmt.Errorf("not connected to master")
+	}
+
+	desktopPath, err := platform.GetDesktopPath()
+	if err != nil {
+		return fmt.Errorf("error getting desktop path: %v", err)
+	}
+
+	domjudgePath := filepath.Join(desktopPath, "DOMJudge")
+	logInfo("Collecting files from %s", domjudgePath)
+
+	count := 0
+	err = filepath.Walk(domjudgePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if info.Size() > session.MaxArtifactSize {
+			logWarning("Skipping %s: file too large (%d bytes)", path, info.Size())
+			return nil
+		}
+
+		relPath, err := filepath.Rel(domjudgePath, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		msg := Message{
+			Type: "artifact",
+			Data: map[string]interface{}{
+				"session":  sessionID,
+				"path":     filepath.ToSlash(relPath),
+				"content":  base64.StdEncoding.EncodeToString(content),
+				"size":     info.Size(),
+				"modified": info.ModTime(),
+			},
+			Timestamp: time.Now(),
+		}
+		if err := c.writeJSON(msg); err != nil {
+			return fmt.Errorf("error sending %s: %v", relPath, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error collecting files: %v", err)
+	}
+
+	logSuccess("Collected %d files", count)
+	return nil
+}
+
+// watchWorkspace streams changes in the DOMJudge folder to the master, which
+// keeps an activity timeline and flags edits made after the deadline.
+func (c *Client) watchWorkspace() {
+	desktopPath, err := platform.GetDesktopPath()
+	if err != nil {
+		logWarning("Workspace watcher disabled: %v", err)
+		return
+	}
+
+	w := watch.New(filepath.Join(desktopPath, "DOMJudge"))
+	lastErr := ""
+	w.Run(WorkspacePollInterval, c.shutdown, c.sendFileEvent, func(err error) {
+		// Report a failing scan once rather than on every poll
+		if err.Error() != lastErr {
+			logWarning("Workspace scan failed: %v", err)
+			lastErr = err.Error()
+		}
+	})
+}
+
+func (c *Client) sendFileEvent(event watch.Event) {
+	if c.conn != nil {
+		msg := Message{
+			Type:      "file_event",
+			Data:      event,
+			Timestamp: time.Now(),
+		}
+		if err := c.writeJSON(msg); err == nil {
+			return
+		}
+	}
+
+	// Keep the event until the master is reachable again; the oldest events
+	// are dropped first if the client stays offline for long
+	c.pendingMu.Lock()
+	c.pendingEvents = append(c.pendingEvents, event)
+	if len(c.pendingEvents) > maxPendingEvents {
+		c.pendingEvents = c.pendingEvents[len(c.pendingEvents)-maxPendingEvents:]
+	}
+	c.pendingMu.Unlock()
+}
+
+func (c *Client) sendPendingEvents() {
+	c.pendingMu.Lock()
+	events := c.pendingEvents
+	c.pendingEvents = nil
+	c.pendingMu.Unlock()
+
+	if len(events) > 0 {
+		logInfo("Sending %d workspace changes recorded while offline", len(events))
+	}
+	for _, event := range events {
+		c.sendFileEvent(event)
+	}
+}
+
 func (c *Client) sendResult(result map[string]interface{}) {
 	// Check if connection exists
 	if c.conn == nil {
@@ -626,7 +1000,7 @@ func (c *Client) sendResult(result map[string]interface{}) {
 		Timestamp: time.Now(),
 	}
 
-	if err := c.conn.WriteJSON(msg); err != nil {
+	if err := c.writeJSON(msg); err != nil {
 		logError("Error sending result: %v", err)
 		if !c.retrying && !c.shouldNotReconnect {
 			select {
@@ -658,7 +1032,7 @@ func (c *Client) sendActionStatus(action, status, errorMsg string) {
 		Timestamp: time.Now(),
 	}
 
-	if err := c.conn.WriteJSON(msg); err != nil {
+	if err := c.writeJSON(msg); err != nil {
 		logError("Error sending action status: %v", err)
 		if !c.retrying && !c.shouldNotReconnect {
 			select {
@@ -688,7 +1062,7 @@ func (c *Client) sendStatus(status string) {
 		Timestamp: time.Now(),
 	}
 
-	if err := c.conn.WriteJSON(msg); err != nil {
+	if err := c.writeJSON(msg); err != nil {
 		logError("Error sending status: %v", err)
 		if !c.retrying && !c.shouldNotReconnect {
 			select {
@@ -720,7 +1094,7 @@ func (c *Client) startHeartbeat() {
 					Timestamp: time.Now(),
 				}
 
-				if err := c.conn.WriteJSON(msg); err != nil {
+				if err := c.writeJSON(msg); err != nil {
 					logError("Error sending heartbeat: %v", err)
 					return
 				}
@@ -789,6 +1163,7 @@ func main() {
 
 	// Initial connection
 	go client.connectWithRetry()
+	go client.watchWorkspace()
 
 	// Keep client running with auto-reconnect
 	for {
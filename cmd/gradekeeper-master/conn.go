@@ -0,0 +1,29 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// clientConn wraps a websocket connection so that writes from the read loop,
+// HTTP handlers and background monitors are serialized; gorilla/websocket
+// allows a single concurrent writer.
+type clientConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func newClientConn(conn *websocket.Conn) *clientConn {
+	return &clientConn{conn: conn}
+}
+
+func (c *clientConn) WriteJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (c *clientConn) Close() error {
+	return c.conn.Close()
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"gradekeeper/internal/config"
+	"gradekeeper/internal/session"
 	"gradekeeper/internal/templates"
 )
 
@@ -33,26 +34,34 @@ type Message struct {
 }
 
 type Command struct {
-	Action string `json:"action"`
-	Target string `json:"target,omitempty"` // "all" or specific client ID
+	Action  string                 `json:"action"`
+	Target  string                 `json:"target,omitempty"`  // "all" or specific client ID
+	Session string                 `json:"session,omitempty"` // exam session the command belongs to
+	Files   []config.WorkspaceFile `json:"files,omitempty"`   // workspace template for "setup"
 }
 
 type ClientInfo struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	Status        string    `json:"status"`
-	LastSeen      time.Time `json:"lastSeen"`
-	FirstSeen     time.Time `json:"firstSeen"`
-	LastHeartbeat time.Time `json:"lastHeartbeat"`
-	Action        string    `json:"action"`       // Current/last action: "setup", "setupAll", "clear", etc.
-	ActionStatus  string    `json:"actionStatus"` // "running", "success", "failed"
-	ActionError   string    `json:"actionError"`  // Error message if failed
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Status          string    `json:"status"`
+	LastSeen        time.Time `json:"lastSeen"`
+	FirstSeen       time.Time `json:"firstSeen"`
+	LastHeartbeat   time.Time `json:"lastHeartbeat"`
+	Action          string    `json:"action"`                    // Current/last action: "setup", "setupAll", "clear", etc.
+	ActionStatus    string    `json:"actionStatus"`              // "running", "success", "failed"
+	ActionError     string    `json:"actionError"`               // Error message if failed
+	PublicKey       string    `json:"publicKey,omitempty"`       // Signing key registered at handshake (hex)
+	KeyMismatch     bool      `json:"keyMismatch"`               // Client presented a different key since registering
+	Receipt         string    `json:"receipt,omitempty"`         // Last receipt status: "verified" or "invalid"
+	ReceiptAt       time.Time `json:"receiptAt,omitempty"`       // When the last receipt was received
+	LateEdits       int       `json:"lateEdits"`                 // Changes after the end time of LateEditSession
+	LateEditSession string    `json:"lateEditSession,omitempty"` // Session the late edits were counted for
 }
 
 type Master struct {
-	clients           map[string]*websocket.Conn
+	clients           map[string]*clientConn
 	clientsInfo       map[string]*ClientInfo
-	dashboardConns    map[*websocket.Conn]bool
+	dashboardConns    map[*clientConn]bool
 	clientsMu         sync.RWMutex
 	dashboardMu       sync.RWMutex
 	configMu          sync.RWMutex
@@ -62,6 +71,10 @@ type Master struct {
 	configFile        string
 	appConfig         config.AppConfig
 	dashboardTemplate *templates.Dashboard
+	sessionsMu        sync.RWMutex
+	sessions          map[string]*session.ExamSession
+	activeSessionID   string
+	sessionsDir       string
 }
 
 func NewMaster() *Master {
@@ -72,9 +85,9 @@ func NewMaster() *Master {
 	}
 
 	m := &Master{
-		clients:        make(map[string]*websocket.Conn),
+		clients:        make(map[string]*clientConn),
 		clientsInfo:    make(map[string]*ClientInfo),
-		dashboardConns: make(map[*websocket.Conn]bool),
+		dashboardConns: make(map[*clientConn]bool),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for development
@@ -85,14 +98,18 @@ func NewMaster() *Master {
 		configFile:        "gradekeeper-config.json",
 		appConfig:         config.DefaultAppConfig(),
 		dashboardTemplate: dashboardTemplate,
+		sessions:          make(map[string]*session.ExamSession),
+		sessionsDir:       "gradekeeper-sessions",
 	}
 
 	// Load existing client data
 	m.loadClientData()
 	m.loadConfig()
+	m.loadSessions()
 
 	// Start heartbeat monitor
 	go m.monitorHeartbeats()
+	go m.monitorSessions()
 
 	return m
 }
@@ -193,6 +210,16 @@ func (m *Master) currentConfig() config.AppConfig {
 	}
 }
 
+// configForClient returns the configuration a client should use, applying the
+// URLs of the exam session it is assigned to.
+func (m *Master) configForClient(clientID string) config.AppConfig {
+	cfg := m.currentConfig()
+	if s, ok := m.sessionForClient(clientID); ok && s.Phase != session.PhaseScheduled && len(s.URLs) > 0 {
+		cfg.URLs = s.URLs
+	}
+	return cfg
+}
+
 func (m *Master) broadcastConfigUpdate() {
 	cfg := m.currentConfig()
 	msg := Message{
@@ -203,7 +230,9 @@ func (m *Master) broadcastConfigUpdate() {
 
 	m.clientsMu.RLock()
 	for clientID, conn := range m.clients {
-		if err := conn.WriteJSON(msg); err != nil {
+		clientMsg := msg
+		clientMsg.Data = m.configForClient(clientID)
+		if err := conn.WriteJSON(clientMsg); err != nil {
 			log.Printf("Error sending config to client %s: %v", clientID, err)
 		}
 	}
@@ -213,7 +242,7 @@ func (m *Master) broadcastConfigUpdate() {
 }
 
 func (m *Master) sendConfigToClient(clientID string) {
-	cfg := m.currentConfig()
+	cfg := m.configForClient(clientID)
 	msg := Message{
 		Type:      "config_update",
 		Data:      cfg,
@@ -339,6 +368,7 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer conn.Close()
+	wc := newClientConn(conn)
 
 	clientID := r.Header.Get("X-Client-ID")
 	// Check for dashboard authentication via query parameter
@@ -349,7 +379,7 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		if dashboardAuth == m.dashboardSecret {
 			// This is a dashboard connection
 			m.dashboardMu.Lock()
-			m.dashboardConns[conn] = true
+			m.dashboardConns[wc] = true
 			m.dashboardMu.Unlock()
 
 			log.Println("Dashboard connected")
@@ -360,7 +390,7 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 				Data:      map[string]string{"type": "dashboard"},
 				Timestamp: time.Now(),
 			}
-			conn.WriteJSON(welcomeMsg)
+			wc.WriteJSON(welcomeMsg)
 
 			// Handle messages from dashboard (if any)
 			for {
@@ -375,7 +405,7 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 			// Remove dashboard connection on disconnect
 			m.dashboardMu.Lock()
-			delete(m.dashboardConns, conn)
+			delete(m.dashboardConns, wc)
 			m.dashboardMu.Unlock()
 			return
 		} else {
@@ -409,12 +439,12 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			},
 			Timestamp: time.Now(),
 		}
-		conn.WriteJSON(rejectMsg)
+		wc.WriteJSON(rejectMsg)
 		conn.Close()
 		return
 	}
 
-	m.clients[clientID] = conn
+	m.clients[clientID] = wc
 
 	// Update or create client info
 	now := time.Now()
@@ -434,6 +464,7 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			LastHeartbeat: now,
 		}
 	}
+	registerClientKey(m.clientsInfo[clientID], r.Header.Get("X-Client-Key"))
 	m.clientsMu.Unlock()
 
 	// Save updated client data
@@ -457,7 +488,7 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		Data:      map[string]string{"clientId": clientID},
 		Timestamp: time.Now(),
 	}
-	conn.WriteJSON(welcomeMsg)
+	wc.WriteJSON(welcomeMsg)
 	m.sendConfigToClient(clientID)
 
 	// Handle messages from client
@@ -474,7 +505,9 @@ func (m *Master) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	// Mark client as disconnected
 	m.clientsMu.Lock()
-	delete(m.clients, clientID)
+	if m.clients[clientID] == wc {
+		delete(m.clients, clientID)
+	}
 	if clientInfo, exists := m.clientsInfo[clientID]; exists {
 		clientInfo.Status = "disconnected"
 		clientInfo.LastSeen = time.Now()
@@ -532,6 +565,15 @@ func (m *Master) handleClientMessage(clientID string, msg Message) {
 		m.saveClientData()
 	case "config_request":
 		m.sendConfigToClient(clientID)
+	case "artifact":
+		// Client sending a collected workspace file
+		m.storeArtifact(clientID, msg.Data)
+	case "receipt":
+		// Client sending its signed pencils-down manifest
+		m.handleReceipt(clientID, msg.Data)
+	case "file_event":
+		// Client reporting a change in its workspace
+		m.handleFileEvent(clientID, msg.Data)
 	}
 }
 
@@ -560,6 +602,14 @@ func (m *Master) handleActionStatus(clientID string, data interface{}) {
 	// Save updated client data
 	m.saveClientData()
 
+	if s, ok := m.sessionForClient(clientID); ok {
+		if errorMsg != "" {
+			m.appendSessionLog(s.ID, "client %s: %s -> %s (%s)", clientID, action, status, errorMsg)
+		} else {
+			m.appendSessionLog(s.ID, "client %s: %s -> %s", clientID, action, status)
+		}
+	}
+
 	// Broadcast to dashboards for real-time updates
 	m.broadcastToDashboard(Message{
 		Type: "client_action_update",
@@ -613,14 +663,22 @@ func (m *Master) broadcastCommand(cmd Command) {
 
 func (m *Master) broadcastToDashboard(msg Message) {
 	m.dashboardMu.RLock()
-	defer m.dashboardMu.RUnlock()
-
+	var failed []*clientConn
 	for conn := range m.dashboardConns {
 		if err := conn.WriteJSON(msg); err != nil {
 			log.Printf("Error sending to dashboard: %v", err)
-			// Remove failed connection
+			failed = append(failed, conn)
+		}
+	}
+	m.dashboardMu.RUnlock()
+
+	// Remove failed connections
+	if len(failed) > 0 {
+		m.dashboardMu.Lock()
+		for _, conn := range failed {
 			delete(m.dashboardConns, conn)
 		}
+		m.dashboardMu.Unlock()
 	}
 }
 
@@ -675,6 +733,14 @@ func (m *Master) handleAPICommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A freeze sent from the dashboard, e.g. at the end of a student's
+	// extension, belongs to the session in progress so its receipt is kept
+	if cmd.Action == "freeze" && cmd.Session == "" {
+		if s, ok := m.activeSession(); ok {
+			cmd.Session = s.ID
+		}
+	}
+
 	m.broadcastCommand(cmd)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"status": "sent"})
@@ -785,8 +851,15 @@ func main() {
 	http.HandleFunc("/ws", master.handleWebSocket)
 	http.HandleFunc("/api/command", master.handleAPICommand)
 	http.HandleFunc("/api/clients", master.handleAPIClients)
+	http.HandleFunc("/api/clients/key", master.handleAPIClientKey)
 	http.HandleFunc("/api/files", master.handleAPIFiles)
 	http.HandleFunc("/api/config", master.handleAPIConfig)
+	http.HandleFunc("/api/sessions", master.handleAPISessions)
+	http.HandleFunc("/api/sessions/phase", master.handleAPISessionPhase)
+	http.HandleFunc("/api/sessions/records", master.handleAPISessionRecords)
+	http.HandleFunc("/api/sessions/file", master.handleAPISessionFile)
+	http.HandleFunc("/api/sessions/timeline", master.handleAPISessionTimeline)
+	http.HandleFunc("/api/sessions/late", master.handleAPISessionLateEdits)
 
 	addr := fmt.Sprintf(":%d", *port)
 
@@ -0,0 +1,776 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gradekeeper/internal/config"
+	"gradekeeper/internal/receipt"
+	"gradekeeper/internal/session"
+)
+
+// Artifact is a workspace file collected from a client at the end of a session.
+type Artifact struct {
+	Session  string    `json:"session"`
+	Path     string    `json:"path"`
+	Content  string    `json:"content"` // base64 encoded
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+func (m *Master) loadSessions() {
+	entries, err := os.ReadDir(m.sessionsDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading sessions directory: %v", err)
+		}
+		return
+	}
+
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+
+	var latest *session.ExamSession
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(m.sessionsDir, entry.Name(), "session.json"))
+		if err != nil {
+			continue
+		}
+
+		var s session.ExamSession
+		if err := json.Unmarshal(data, &s); err != nil {
+			log.Printf("Error parsing session %s: %v", entry.Name(), err)
+			continue
+		}
+
+		m.sessions[s.ID] = &s
+		if s.Active() && (latest == nil || s.CreatedAt.After(latest.CreatedAt)) {
+			latest = &s
+		}
+	}
+
+	if latest != nil {
+		m.activeSessionID = latest.ID
+	}
+
+	log.Printf("Loaded %d exam sessions from storage", len(m.sessions))
+}
+
+func (m *Master) sessionDir(id string) string {
+	return filepath.Join(m.sessionsDir, id)
+}
+
+// saveSession persists the session; callers must hold sessionsMu.
+func (m *Master) saveSession(s *session.ExamSession) error {
+	dir := m.sessionDir(s.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "session.json"), data, 0644)
+}
+
+// appendSessionLog records an event in the session's log file.
+func (m *Master) appendSessionLog(id string, format string, args ...interface{}) {
+	dir := m.sessionDir(id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("Error creating session directory: %v", err)
+		return
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, "events.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Error opening session log: %v", err)
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// activeSession returns a copy of the session currently in progress.
+func (m *Master) activeSession() (session.ExamSession, bool) {
+	m.sessionsMu.RLock()
+	defer m.sessionsMu.RUnlock()
+
+	s, exists := m.sessions[m.activeSessionID]
+	if !exists || !s.Active() {
+		return session.ExamSession{}, false
+	}
+	return s.Copy(), true
+}
+
+// sessionForClient returns the active session the client is assigned to.
+func (m *Master) sessionForClient(clientID string) (session.ExamSession, bool) {
+	s, ok := m.activeSession()
+	if !ok || !s.HasClient(clientID) {
+		return session.ExamSession{}, false
+	}
+	return s, true
+}
+
+func (m *Master) listSessions() []session.ExamSession {
+	m.sessionsMu.RLock()
+	defer m.sessionsMu.RUnlock()
+
+	sessions := make([]session.ExamSession, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s.Copy())
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions
+}
+
+func (m *Master) createSession(s *session.ExamSession) error {
+	s.URLs = config.Normalize(config.AppConfig{URLs: s.URLs}).URLs
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	m.sessionsMu.Lock()
+	current, exists := m.sessions[m.activeSessionID]
+	if exists && current.Active() && current.Phase != session.PhaseScheduled {
+		m.sessionsMu.Unlock()
+		return fmt.Errorf("session %q is still in progress", current.Name)
+	}
+
+	// A scheduled session that never started is retired so it can no longer
+	// be moved into later phases
+	var replaced *session.ExamSession
+	if exists && current.Active() {
+		current.Phase = session.PhaseClear
+		current.PhaseChangedAt = time.Now()
+		if err := m.saveSession(current); err != nil {
+			log.Printf("Error saving session %s: %v", current.ID, err)
+		}
+		replaced = current
+	}
+
+	m.sessions[s.ID] = s
+	m.activeSessionID = s.ID
+	err := m.saveSession(s)
+	snapshot := s.Copy()
+	m.sessionsMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if replaced != nil {
+		m.appendSessionLog(replaced.ID, "session replaced by %s before it started", s.ID)
+		m.broadcastSessionUpdate(replaced.Copy())
+	}
+
+	m.appendSessionLog(s.ID, "session %q created (%s - %s)", s.Name, s.StartTime.Format(time.RFC3339), s.EndTime.Format(time.RFC3339))
+	m.broadcastSessionUpdate(snapshot)
+	return nil
+}
+
+// transitionSession moves a session to a new phase and triggers the client
+// actions associated with it.
+func (m *Master) transitionSession(id string, phase session.Phase) error {
+	m.sessionsMu.Lock()
+	s, exists := m.sessions[id]
+	if !exists {
+		m.sessionsMu.Unlock()
+		return fmt.Errorf("session not found: %s", id)
+	}
+	if id != m.activeSessionID || !s.Active() {
+		m.sessionsMu.Unlock()
+		return fmt.Errorf("session %s is not the active session", id)
+	}
+
+	from := s.Phase
+	if err := s.Transition(phase); err != nil {
+		m.sessionsMu.Unlock()
+		return err
+	}
+
+	err := m.saveSession(s)
+	snapshot := s.Copy()
+	m.sessionsMu.Unlock()
+
+	if err != nil {
+		log.Printf("Error saving session %s: %v", id, err)
+	}
+
+	log.Printf("Session %s moved from %s to %s", id, from, phase)
+	m.appendSessionLog(id, "phase %s -> %s", from, phase)
+	m.broadcastSessionUpdate(snapshot)
+	m.runPhaseActions(snapshot)
+	return nil
+}
+
+func (m *Master) runPhaseActions(s session.ExamSession) {
+	if s.Phase == session.PhasePrepare || s.Phase == session.PhaseClear {
+		// Session URLs apply from prepare until the session is cleared
+		for _, clientID := range m.sessionTargets(s) {
+			m.sendConfigToClient(clientID)
+		}
+	}
+
+	for _, action := range s.Phase.Actions() {
+		cmd := Command{
+			Action:  action,
+			Session: s.ID,
+		}
+		if action == "setup" {
+			cmd.Files = s.Workspace
+		}
+
+		if len(s.Clients) == 0 {
+			cmd.Target = "all"
+			m.broadcastCommand(cmd)
+			m.appendSessionLog(s.ID, "command %s sent to all clients", action)
+			continue
+		}
+
+		for _, clientID := range s.Clients {
+			cmd.Target = clientID
+			m.broadcastCommand(cmd)
+		}
+		m.appendSessionLog(s.ID, "command %s sent to %d clients", action, len(s.Clients))
+	}
+}
+
+// sessionTargets resolves the clients taking part in a session.
+func (m *Master) sessionTargets(s session.ExamSession) []string {
+	if len(s.Clients) > 0 {
+		return s.Clients
+	}
+
+	m.clientsMu.RLock()
+	defer m.clientsMu.RUnlock()
+
+	ids := make([]string, 0, len(m.clients))
+	for clientID := range m.clients {
+		ids = append(ids, clientID)
+	}
+	return ids
+}
+
+// monitorSessions drives sessions by the clock: at the start time a session
+// that has not been started yet is stepped through to running, and once the
+// end time passes a started session moves to pencils-down, even if the
+// proctor never moved it to running.
+func (m *Master) monitorSessions() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s, ok := m.activeSession()
+		if !ok {
+			continue
+		}
+
+		now := time.Now()
+		switch {
+		case s.Phase != session.PhaseScheduled && s.Phase.BeforeDeadline() && !now.Before(s.EndTime):
+			log.Printf("Session %s reached its end time", s.ID)
+			if err := m.transitionSession(s.ID, session.PhasePencilsDown); err != nil {
+				log.Printf("Error ending session %s: %v", s.ID, err)
+			}
+		case !now.Before(s.StartTime):
+			steps := session.Steps(s.Phase, session.PhaseRunning)
+			if len(steps) == 0 {
+				continue
+			}
+
+			// Phases the proctor skipped still run their actions, so clients
+			// are set up before the editors open
+			log.Printf("Session %s reached its start time", s.ID)
+			for _, phase := range steps {
+				if err := m.transitionSession(s.ID, phase); err != nil {
+					log.Printf("Error starting session %s: %v", s.ID, err)
+					break
+				}
+			}
+		}
+	}
+}
+
+func (m *Master) broadcastSessionUpdate(s session.ExamSession) {
+	m.broadcastToDashboard(Message{
+		Type:      "session_update",
+		Data:      s,
+		Timestamp: time.Now(),
+	})
+}
+
+// storeArtifact writes a collected file under the session's artifact directory.
+func (m *Master) storeArtifact(clientID string, data interface{}) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Invalid artifact from client %s: %v", clientID, err)
+		return
+	}
+
+	var artifact Artifact
+	if err := json.Unmarshal(bytes, &artifact); err != nil {
+		log.Printf("Invalid artifact from client %s: %v", clientID, err)
+		return
+	}
+
+	s, ok := m.sessionForClient(clientID)
+	if !ok || s.ID != artifact.Session || s.Phase != session.PhaseCollect {
+		log.Printf("Rejected artifact from client %s: session %q is not collecting from it", clientID, artifact.Session)
+		return
+	}
+
+	relPath, err := config.CleanRelativePath(artifact.Path)
+	if err != nil {
+		log.Printf("Rejected artifact with unsafe path from client %s: %s", clientID, artifact.Path)
+		return
+	}
+
+	if base64.StdEncoding.DecodedLen(len(artifact.Content)) > session.MaxArtifactSize+2 {
+		log.Printf("Rejected artifact %s from client %s: file too large", artifact.Path, clientID)
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(artifact.Content)
+	if err != nil {
+		log.Printf("Invalid artifact content from client %s: %v", clientID, err)
+		return
+	}
+	if len(content) > session.MaxArtifactSize {
+		log.Printf("Rejected artifact %s from client %s: file too large", artifact.Path, clientID)
+		return
+	}
+
+	dest := filepath.Join(m.sessionDir(artifact.Session), "artifacts", sanitizeFileName(clientID), relPath)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		log.Printf("Error creating artifact directory: %v", err)
+		return
+	}
+	if err := os.WriteFile(dest, content, 0644); err != nil {
+		log.Printf("Error writing artifact: %v", err)
+		return
+	}
+	if !artifact.Modified.IsZero() {
+		os.Chtimes(dest, artifact.Modified, artifact.Modified)
+	}
+
+	m.appendSessionLog(artifact.Session, "artifact %s collected from %s (%d bytes)", filepath.ToSlash(relPath), clientID, len(content))
+}
+
+// handleReceipt verifies a signed pencils-down manifest and stores it with the
+// client's session. Receipts must be signed with the key the client registered
+// during its handshake; anything else is stored apart as rejected.
+func (m *Master) handleReceipt(clientID string, data interface{}) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Invalid receipt from client %s: %v", clientID, err)
+		return
+	}
+
+	var signed receipt.Receipt
+	if err := json.Unmarshal(bytes, &signed); err != nil {
+		log.Printf("Invalid receipt from client %s: %v", clientID, err)
+		return
+	}
+
+	manifest, verifyErr := signed.Verify()
+	if verifyErr == nil && manifest.ClientID != clientID {
+		verifyErr = fmt.Errorf("manifest belongs to another client")
+	}
+
+	publicKey := hex.EncodeToString(signed.PublicKey)
+	m.clientsMu.RLock()
+	if clientInfo, exists := m.clientsInfo[clientID]; verifyErr == nil {
+		switch {
+		case !exists || clientInfo.PublicKey == "":
+			verifyErr = fmt.Errorf("client has no registered signing key")
+		case clientInfo.PublicKey != publicKey:
+			verifyErr = fmt.Errorf("signing key does not match registered key")
+		}
+	}
+	m.clientsMu.RUnlock()
+
+	s, ok := m.sessionForClient(clientID)
+	if verifyErr == nil && (!ok || s.ID != manifest.Session) {
+		verifyErr = fmt.Errorf("client is not assigned to session %q", manifest.Session)
+	}
+	if verifyErr == nil && s.Phase != session.PhasePencilsDown && s.Phase != session.PhaseCollect {
+		verifyErr = fmt.Errorf("session is in phase %s, not pencils-down", s.Phase)
+	}
+
+	status := "verified"
+	if verifyErr != nil {
+		status = "invalid"
+		log.Printf("Receipt from client %s rejected: %v", clientID, verifyErr)
+	} else {
+		log.Printf("Receipt from client %s verified (%d files)", clientID, len(manifest.Files))
+	}
+
+	m.clientsMu.Lock()
+	if clientInfo, exists := m.clientsInfo[clientID]; exists {
+		clientInfo.Receipt = status
+		clientInfo.ReceiptAt = time.Now()
+	}
+	m.clientsMu.Unlock()
+	m.saveClientData()
+
+	// Every receipt is kept with a timestamp so a freeze after an extension
+	// does not replace the one taken at the deadline
+	if ok {
+		dir := filepath.Join(m.sessionDir(s.ID), "receipts")
+		if verifyErr != nil {
+			dir = filepath.Join(dir, "rejected")
+		}
+		name := fmt.Sprintf("%s-%s.json", sanitizeFileName(clientID), time.Now().Format("20060102-150405.000"))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Printf("Error creating receipts directory: %v", err)
+		} else if err := os.WriteFile(filepath.Join(dir, name), bytes, 0644); err != nil {
+			log.Printf("Error saving receipt: %v", err)
+		}
+
+		if verifyErr != nil {
+			m.appendSessionLog(s.ID, "receipt from %s rejected: %v", clientID, verifyErr)
+		} else {
+			m.appendSessionLog(s.ID, "receipt from %s verified (%d files)", clientID, len(manifest.Files))
+		}
+	}
+
+	m.broadcastToDashboard(Message{
+		Type: "receipt",
+		Data: map[string]interface{}{
+			"clientId": clientID,
+			"status":   status,
+			"files":    len(manifest.Files),
+			"error":    errorToString(verifyErr),
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// registerClientKey pins the receipt signing key a client presents in its
+// handshake. A different key for a known client is not accepted; it is
+// flagged for the proctor, who can reset the registration from the dashboard.
+// Callers must hold clientsMu.
+func registerClientKey(clientInfo *ClientInfo, key string) {
+	if key == "" {
+		return
+	}
+	if decoded, err := hex.DecodeString(key); err != nil || len(decoded) != ed25519.PublicKeySize {
+		log.Printf("Client %s presented an invalid signing key", clientInfo.ID)
+		return
+	}
+
+	switch clientInfo.PublicKey {
+	case "":
+		clientInfo.PublicKey = key
+		clientInfo.KeyMismatch = false
+		log.Printf("Registered signing key for client %s", clientInfo.ID)
+	case key:
+		clientInfo.KeyMismatch = false
+	default:
+		clientInfo.KeyMismatch = true
+		log.Printf("Client %s presented a signing key that does not match its registered key", clientInfo.ID)
+	}
+}
+
+func (m *Master) handleAPIClientKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientID := r.URL.Query().Get("clientId")
+	m.clientsMu.Lock()
+	clientInfo, exists := m.clientsInfo[clientID]
+	if exists {
+		clientInfo.PublicKey = ""
+		clientInfo.KeyMismatch = false
+	}
+	m.clientsMu.Unlock()
+
+	if !exists {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+
+	m.saveClientData()
+	log.Printf("Signing key registration reset for client %s", clientID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "reset"})
+}
+
+func errorToString(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// sanitizeFileName makes a client ID safe to use as a directory name.
+func sanitizeFileName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':':
+			return '_'
+		}
+		return r
+	}, name)
+	if cleaned == "" || cleaned == "." || cleaned == ".." {
+		return "_"
+	}
+	return cleaned
+}
+
+func (m *Master) handleAPISessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		active, _ := m.activeSession()
+		var activePtr *session.ExamSession
+		if active.ID != "" {
+			activePtr = &active
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"active":   activePtr,
+			"sessions": m.listSessions(),
+			"phases":   session.Phases(),
+		})
+	case http.MethodPost:
+		var req struct {
+			Name      string                 `json:"name"`
+			StartTime time.Time              `json:"startTime"`
+			EndTime   time.Time              `json:"endTime"`
+			Clients   []string               `json:"clients"`
+			URLs      []string               `json:"urls"`
+			Workspace []config.WorkspaceFile `json:"workspace"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		s := session.New(req.Name, req.StartTime, req.EndTime)
+		for _, clientID := range req.Clients {
+			if clientID = strings.TrimSpace(clientID); clientID != "" {
+				s.Clients = append(s.Clients, clientID)
+			}
+		}
+		s.URLs = req.URLs
+		s.Workspace = req.Workspace
+
+		if err := m.createSession(s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Copy())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// sessionRecords lists what has been recorded for a session on disk.
+type sessionRecords struct {
+	Artifacts []recordFile `json:"artifacts"`
+	Receipts  []recordFile `json:"receipts"`
+	Events    []string     `json:"events"`
+}
+
+// recordFile is a file in a session directory. Path is relative to the
+// session directory and can be fetched from /api/sessions/file.
+type recordFile struct {
+	Client   string    `json:"client,omitempty"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Rejected bool      `json:"rejected,omitempty"`
+}
+
+func (m *Master) sessionRecords(id string) (sessionRecords, error) {
+	records := sessionRecords{
+		Artifacts: []recordFile{},
+		Receipts:  []recordFile{},
+		Events:    []string{},
+	}
+	dir := m.sessionDir(id)
+
+	artifactsDir := filepath.Join(dir, "artifacts")
+	err := filepath.Walk(artifactsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(artifactsDir, path)
+		if err != nil {
+			return err
+		}
+		client, file, _ := strings.Cut(filepath.ToSlash(relPath), "/")
+		records.Artifacts = append(records.Artifacts, recordFile{
+			Client:   client,
+			Path:     "artifacts/" + client + "/" + file,
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return records, err
+	}
+
+	for _, sub := range []string{"receipts", "receipts/rejected"} {
+		entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(sub)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return records, err
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			records.Receipts = append(records.Receipts, recordFile{
+				Path:     sub + "/" + entry.Name(),
+				Size:     info.Size(),
+				Modified: info.ModTime(),
+				Rejected: sub != "receipts",
+			})
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "events.log"))
+	if err != nil && !os.IsNotExist(err) {
+		return records, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			records.Events = append(records.Events, line)
+		}
+	}
+
+	return records, nil
+}
+
+func (m *Master) knownSession(id string) bool {
+	m.sessionsMu.RLock()
+	defer m.sessionsMu.RUnlock()
+	_, exists := m.sessions[id]
+	return exists
+}
+
+func (m *Master) handleAPISessionRecords(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if !m.knownSession(id) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	records, err := m.sessionRecords(id)
+	if err != nil {
+		log.Printf("Error reading records of session %s: %v", id, err)
+		http.Error(w, "Failed to read session records", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+// handleAPISessionFile serves a collected artifact, receipt or the event log
+// of a session.
+func (m *Master) handleAPISessionFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if !m.knownSession(id) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	relPath, err := config.CleanRelativePath(r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeFile(w, r, filepath.Join(m.sessionDir(id), relPath))
+}
+
+func (m *Master) handleAPISessionPhase(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID    string `json:"id"`
+		Phase string `json:"phase"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	phase, err := session.ParsePhase(req.Phase)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.ID == "" {
+		active, ok := m.activeSession()
+		if !ok {
+			http.Error(w, "No active session", http.StatusNotFound)
+			return
+		}
+		req.ID = active.ID
+	}
+
+	if err := m.transitionSession(req.ID, phase); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok", "phase": string(phase)})
+}
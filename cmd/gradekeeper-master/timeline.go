@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gradekeeper/internal/config"
+	"gradekeeper/internal/session"
+	"gradekeeper/internal/watch"
+)
+
+// TimelineEvent is a workspace change reported by a client, as stored in the
+// client's activity timeline for the session.
+type TimelineEvent struct {
+	ClientID string `json:"clientId"`
+	watch.Event
+	Received time.Time `json:"received"`
+	Late     bool      `json:"late"`
+}
+
+func (m *Master) timelineFile(sessionID, clientID string) string {
+	return filepath.Join(m.sessionDir(sessionID), "timeline", sanitizeFileName(clientID)+".jsonl")
+}
+
+// handleFileEvent records a change in a client's workspace and flags it when
+// it happened after the end time of the client's session.
+func (m *Master) handleFileEvent(clientID string, data interface{}) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Invalid file event from client %s: %v", clientID, err)
+		return
+	}
+
+	var event watch.Event
+	if err := json.Unmarshal(bytes, &event); err != nil {
+		log.Printf("Invalid file event from client %s: %v", clientID, err)
+		return
+	}
+	if _, err := config.CleanRelativePath(event.Path); err != nil {
+		log.Printf("Rejected file event with unsafe path from client %s: %s", clientID, event.Path)
+		return
+	}
+	switch event.Op {
+	case watch.OpCreate, watch.OpWrite, watch.OpRemove:
+	default:
+		log.Printf("Rejected file event with unknown operation from client %s: %s", clientID, event.Op)
+		return
+	}
+
+	s, ok := m.sessionForClient(clientID)
+	if !ok || s.Phase == session.PhaseScheduled {
+		return
+	}
+
+	entry := TimelineEvent{
+		ClientID: clientID,
+		Event:    event,
+		Received: time.Now(),
+	}
+	// Events queued while the client was offline keep the time they were
+	// observed; a missing or future time falls back to the time of arrival
+	if entry.Time.IsZero() || entry.Time.After(entry.Received) {
+		entry.Time = entry.Received
+	}
+	entry.Late = !entry.Time.Before(s.EndTime)
+
+	if err := m.appendTimeline(s.ID, entry); err != nil {
+		log.Printf("Error saving file event from client %s: %v", clientID, err)
+	}
+
+	if entry.Late {
+		m.clientsMu.Lock()
+		if clientInfo, exists := m.clientsInfo[clientID]; exists {
+			if clientInfo.LateEditSession != s.ID {
+				clientInfo.LateEditSession = s.ID
+				clientInfo.LateEdits = 0
+			}
+			clientInfo.LateEdits++
+		}
+		m.clientsMu.Unlock()
+		m.saveClientData()
+
+		log.Printf("Late edit by client %s: %s %s", clientID, event.Op, event.Path)
+		m.appendSessionLog(s.ID, "late edit by %s: %s %s (%d bytes)", clientID, event.Op, event.Path, event.Size)
+	}
+
+	m.broadcastToDashboard(Message{
+		Type:      "file_event",
+		Data:      entry,
+		Timestamp: time.Now(),
+	})
+}
+
+func (m *Master) appendTimeline(sessionID string, entry TimelineEvent) error {
+	path := m.timelineFile(sessionID, entry.ClientID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func readTimeline(path string) ([]TimelineEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TimelineEvent{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	events := []TimelineEvent{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry TimelineEvent
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		events = append(events, entry)
+	}
+	return events, scanner.Err()
+}
+
+// lateEdits returns every change made after the end time of a session,
+// oldest first.
+func (m *Master) lateEdits(sessionID string) ([]TimelineEvent, error) {
+	dir := filepath.Join(m.sessionDir(sessionID), "timeline")
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	late := []TimelineEvent{}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		events, err := readTimeline(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", entry.Name(), err)
+		}
+		for _, event := range events {
+			if event.Late {
+				late = append(late, event)
+			}
+		}
+	}
+
+	sort.Slice(late, func(i, j int) bool {
+		return late[i].Time.Before(late[j].Time)
+	})
+	return late, nil
+}
+
+func (m *Master) handleAPISessionTimeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	clientID := r.URL.Query().Get("clientId")
+	if !m.knownSession(id) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if clientID == "" {
+		http.Error(w, "clientId is required", http.StatusBadRequest)
+		return
+	}
+
+	events, err := readTimeline(m.timelineFile(id, clientID))
+	if err != nil {
+		log.Printf("Error reading timeline of client %s: %v", clientID, err)
+		http.Error(w, "Failed to read timeline", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func (m *Master) handleAPISessionLateEdits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if !m.knownSession(id) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	late, err := m.lateEdits(id)
+	if err != nil {
+		log.Printf("Error reading late edits of session %s: %v", id, err)
+		http.Error(w, "Failed to read late edits", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(late)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gradekeeper/internal/config"
+	"gradekeeper/internal/session"
+	"gradekeeper/internal/watch"
+)
+
+// newTestMaster returns a master that keeps its files in a temporary
+// directory and runs no background monitors.
+func newTestMaster(t *testing.T) *Master {
+	t.Helper()
+	dir := t.TempDir()
+	return &Master{
+		clients:        make(map[string]*clientConn),
+		clientsInfo:    make(map[string]*ClientInfo),
+		dashboardConns: make(map[*clientConn]bool),
+		storageFile:    filepath.Join(dir, "clients.json"),
+		configFile:     filepath.Join(dir, "config.json"),
+		appConfig:      config.DefaultAppConfig(),
+		sessions:       make(map[string]*session.ExamSession),
+		sessionsDir:    filepath.Join(dir, "sessions"),
+	}
+}
+
+// startTestSession creates a session for the given clients and moves it to phase.
+func startTestSession(t *testing.T, m *Master, start, end time.Time, phase session.Phase, clients ...string) session.ExamSession {
+	t.Helper()
+	s := session.New("exam", start, end)
+	s.Clients = clients
+	s.Phase = phase
+	if err := m.createSession(s); err != nil {
+		t.Fatal(err)
+	}
+	return s.Copy()
+}
+
+func TestHandleFileEventFlagsLateEdits(t *testing.T) {
+	m := newTestMaster(t)
+	m.clientsInfo["lab01"] = &ClientInfo{ID: "lab01"}
+	end := time.Now().Add(-time.Minute)
+	s := startTestSession(t, m, end.Add(-time.Hour), end, session.PhasePencilsDown, "lab01")
+
+	onTime := watch.Event{Path: "main.cpp", Op: watch.OpWrite, Time: end.Add(-time.Second), Size: 10}
+	late := watch.Event{Path: "main.cpp", Op: watch.OpWrite, Time: end.Add(time.Second), Size: 12}
+	m.handleFileEvent("lab01", onTime)
+	m.handleFileEvent("lab01", late)
+
+	// Not assigned to the session, unsafe path and unknown operation are dropped
+	m.handleFileEvent("lab02", late)
+	m.handleFileEvent("lab01", watch.Event{Path: "../x", Op: watch.OpWrite, Time: late.Time})
+	m.handleFileEvent("lab01", watch.Event{Path: "x", Op: "chmod", Time: late.Time})
+
+	events, err := readTimeline(m.timelineFile(s.ID, "lab01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("timeline has %d events, want 2", len(events))
+	}
+	if events[0].Late || !events[1].Late {
+		t.Errorf("late flags = %v, %v; want false, true", events[0].Late, events[1].Late)
+	}
+
+	edits, err := m.lateEdits(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 || edits[0].ClientID != "lab01" || edits[0].Size != 12 {
+		t.Errorf("lateEdits() = %+v, want the single late write", edits)
+	}
+
+	if info := m.clientsInfo["lab01"]; info.LateEdits != 1 || info.LateEditSession != s.ID {
+		t.Errorf("client late edits = %d (%s), want 1 (%s)", info.LateEdits, info.LateEditSession, s.ID)
+	}
+}
+
+func TestHandleFileEventFutureTime(t *testing.T) {
+	m := newTestMaster(t)
+	end := time.Now().Add(time.Hour)
+	s := startTestSession(t, m, time.Now().Add(-time.Hour), end, session.PhaseRunning)
+
+	// A client clock far ahead must not turn an edit into a late one
+	m.handleFileEvent("lab01", watch.Event{Path: "a.py", Op: watch.OpCreate, Time: end.Add(time.Hour)})
+
+	events, err := readTimeline(m.timelineFile(s.ID, "lab01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Late {
+		t.Errorf("timeline = %+v, want one on-time event", events)
+	}
+}
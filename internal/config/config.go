@@ -2,6 +2,8 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
 	"strings"
 )
 
@@ -62,3 +64,31 @@ func (cfg AppConfig) Validate() error {
 	}
 	return nil
 }
+
+// WorkspaceFile is a file seeded into a client's workspace during setup.
+type WorkspaceFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Validate ensures the file path stays inside the workspace.
+func (f WorkspaceFile) Validate() error {
+	if strings.TrimSpace(f.Path) == "" {
+		return errors.New("workspace file path is required")
+	}
+	if _, err := CleanRelativePath(f.Path); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CleanRelativePath converts a slash-separated relative path to the host
+// format and rejects paths that are absolute or climb out of their root.
+func CleanRelativePath(path string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(path))
+	if cleaned == "." || filepath.IsAbs(cleaned) || filepath.VolumeName(cleaned) != "" ||
+		cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path must be relative: %s", path)
+	}
+	return cleaned, nil
+}
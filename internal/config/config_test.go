@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestWorkspaceFileValidate(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"main.cpp", false},
+		{"problem-a/main.cpp", false},
+		{"..notes.txt", false},
+		{"a/../main.cpp", false},
+		{"", true},
+		{"..", true},
+		{"../main.cpp", true},
+		{"a/../../main.cpp", true},
+		{"/etc/passwd", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := WorkspaceFile{Path: tt.path}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
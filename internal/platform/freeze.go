@@ -0,0 +1,203 @@
+package platform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// frozenModes records the permissions a folder had before it was frozen so
+// UnfreezeFolder can put them back.
+type frozenModes struct {
+	Folder string                 `json:"folder"`
+	Modes  map[string]os.FileMode `json:"modes"`
+}
+
+// FreezeFolder makes a folder and everything in it read-only, saving the
+// original permissions in the state directory
+func FreezeFolder(folderPath string) error {
+	modesFile, err := frozenModesFile(folderPath)
+	if err != nil {
+		return err
+	}
+	return freezeFolder(folderPath, modesFile)
+}
+
+// UnfreezeFolder restores the permissions a folder had before FreezeFolder
+func UnfreezeFolder(folderPath string) error {
+	modesFile, err := frozenModesFile(folderPath)
+	if err != nil {
+		return err
+	}
+	return unfreezeFolder(folderPath, modesFile)
+}
+
+// frozenModesFile names the state file for a folder after a hash of its path.
+func frozenModesFile(folderPath string) (string, error) {
+	stateDir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	absPath, err := filepath.Abs(folderPath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(absPath))
+	return filepath.Join(stateDir, "frozen-"+hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+func freezeFolder(folderPath, modesFile string) error {
+	absPath, err := filepath.Abs(folderPath)
+	if err != nil {
+		return err
+	}
+
+	saved := frozenModes{Folder: absPath, Modes: make(map[string]os.FileMode)}
+	var files, dirs []string
+
+	err = filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		saved.Modes[relKey(folderPath, path)] = info.Mode().Perm()
+		if info.IsDir() {
+			dirs = append(dirs, path)
+		} else {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read permissions: %v", err)
+	}
+
+	previous, err := readFrozenModes(modesFile)
+	if err != nil {
+		return err
+	}
+
+	// Freezing an already frozen folder must not replace the original modes.
+	// Saved modes are only trusted while the folder is still locked; a file
+	// left behind by a folder that was deleted or unlocked outside GradeKeeper
+	// is replaced.
+	if previous != nil && previous.Folder == saved.Folder && saved.Modes["."]&0200 == 0 {
+		for key, mode := range previous.Modes {
+			if _, exists := saved.Modes[key]; exists {
+				saved.Modes[key] = mode
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(modesFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to save permissions: %v", err)
+	}
+
+	for _, path := range files {
+		if err := os.Chmod(path, saved.Modes[relKey(folderPath, path)]&^0222); err != nil {
+			return fmt.Errorf("failed to freeze %s: %v", path, err)
+		}
+	}
+
+	// Lock directories deepest first so their contents stay reachable
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i], saved.Modes[relKey(folderPath, dirs[i])]&^0222); err != nil {
+			return fmt.Errorf("failed to freeze directory %s: %v", dirs[i], err)
+		}
+	}
+
+	return nil
+}
+
+func unfreezeFolder(folderPath, modesFile string) error {
+	saved, err := readFrozenModes(modesFile)
+	if err != nil {
+		return err
+	}
+	if saved == nil {
+		saved = &frozenModes{Modes: make(map[string]os.FileMode)}
+	}
+
+	restore := func(path string, isDir bool) os.FileMode {
+		if mode, exists := saved.Modes[relKey(folderPath, path)]; exists {
+			return mode
+		}
+		// Not known at freeze time; fall back to the usual defaults
+		if isDir {
+			return 0755
+		}
+		return 0644
+	}
+
+	// Directories must become writable before their contents can be changed,
+	// and filepath.Walk visits parents first
+	var dirs []string
+	err = filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+			return os.Chmod(path, restore(path, true)|0700)
+		}
+		return os.Chmod(path, restore(path, false))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unfreeze folder: %v", err)
+	}
+
+	// Put back the exact directory modes, deepest first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i], restore(dirs[i], true)); err != nil {
+			return fmt.Errorf("failed to unfreeze directory %s: %v", dirs[i], err)
+		}
+	}
+
+	if err := os.Remove(modesFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove saved permissions: %v", err)
+	}
+	return nil
+}
+
+// readFrozenModes loads saved permissions, returning nil if there are none.
+func readFrozenModes(modesFile string) (*frozenModes, error) {
+	data, err := os.ReadFile(modesFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved permissions: %v", err)
+	}
+
+	var saved frozenModes
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid saved permissions: %v", err)
+	}
+	if saved.Modes == nil {
+		saved.Modes = make(map[string]os.FileMode)
+	}
+	return &saved, nil
+}
+
+// relKey returns the slash-separated path of an entry relative to the folder.
+func relKey(folderPath, path string) string {
+	relPath, err := filepath.Rel(folderPath, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(relPath)
+}
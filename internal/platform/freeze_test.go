@@ -0,0 +1,149 @@
+//go:build !windows
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFreezeUnfreezeRestoresModes(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "DOMJudge")
+	modesFile := filepath.Join(t.TempDir(), "frozen.json")
+
+	entries := map[string]os.FileMode{
+		"main.cpp":       0644,
+		"run.sh":         0755,
+		"private.txt":    0600,
+		"problem-a":      0750,
+		"problem-a/a.py": 0640,
+	}
+	if err := os.MkdirAll(filepath.Join(root, "problem-a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, mode := range entries {
+		path := filepath.Join(root, name)
+		if name != "problem-a" {
+			if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := freezeFolder(root, modesFile); err != nil {
+		t.Fatalf("freezeFolder() error = %v", err)
+	}
+	for name, mode := range entries {
+		info, err := os.Stat(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := info.Mode().Perm(), mode&^0222; got != want {
+			t.Errorf("frozen %s mode = %v, want %v", name, got, want)
+		}
+	}
+
+	// A second freeze must not overwrite the saved modes with frozen ones
+	if err := freezeFolder(root, modesFile); err != nil {
+		t.Fatalf("second freezeFolder() error = %v", err)
+	}
+
+	if err := unfreezeFolder(root, modesFile); err != nil {
+		t.Fatalf("unfreezeFolder() error = %v", err)
+	}
+	for name, mode := range entries {
+		info, err := os.Stat(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("unfrozen %s mode = %v, want %v", name, got, mode)
+		}
+	}
+
+	if _, err := os.Stat(modesFile); !os.IsNotExist(err) {
+		t.Error("saved modes not removed after unfreeze")
+	}
+}
+
+func TestUnfreezeWithoutSavedModes(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "main.cpp")
+	if err := os.WriteFile(path, nil, 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unfreezeFolder(root, filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("unfreezeFolder() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+func TestFreezeReplacesStaleModes(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "DOMJudge")
+	modesFile := filepath.Join(t.TempDir(), "frozen.json")
+	path := filepath.Join(root, "main.cpp")
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := freezeFolder(root, modesFile); err != nil {
+		t.Fatalf("freezeFolder() error = %v", err)
+	}
+
+	// The folder is unlocked and recreated behind GradeKeeper's back, leaving
+	// the saved modes file behind
+	if err := os.Chmod(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := freezeFolder(root, modesFile); err != nil {
+		t.Fatalf("freezeFolder() error = %v", err)
+	}
+	if err := unfreezeFolder(root, modesFile); err != nil {
+		t.Fatalf("unfreezeFolder() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestFreezeUnreadableModesFile(t *testing.T) {
+	root := t.TempDir()
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := freezeFolder(root, filepath.Join(notDir, "frozen.json")); err == nil {
+		t.Error("freezeFolder() succeeded with an unreadable modes file")
+	}
+	if err := os.Chmod(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
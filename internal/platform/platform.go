@@ -217,12 +217,33 @@ func openBrowserMacOS(urls []string) error {
 	return nil
 }
 
+// StateDir returns the directory where GradeKeeper keeps its own state
+// (the receipt signing key and the saved permissions of frozen folders),
+// creating it if needed.
+func StateDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+
+	stateDir := filepath.Join(configDir, "gradekeeper")
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create state directory: %v", err)
+	}
+	return stateDir, nil
+}
+
+// CloseEditors closes running VS Code processes
+func CloseEditors() error {
+	return closeVSCode()
+}
+
 // ClearEnvironment removes DOMJudge folder and closes VS Code and browser processes
 func ClearEnvironment() error {
 	var errors []string
 
 	// Remove DOMJudge folder
-	if err := removeDOMJudgeFolder(); err != nil {
+	if err := removeDOMJudgeFolder(&errors); err != nil {
 		errors = append(errors, fmt.Sprintf("failed to remove DOMJudge folder: %v", err))
 	}
 
@@ -243,21 +264,27 @@ func ClearEnvironment() error {
 	return nil
 }
 
-// removeDOMJudgeFolder removes the DOMJudge folder from desktop
-func removeDOMJudgeFolder() error {
+// removeDOMJudgeFolder removes the DOMJudge folder from desktop. Problems that
+// do not stop the removal are added to warnings.
+func removeDOMJudgeFolder(warnings *[]string) error {
 	desktopPath, err := GetDesktopPath()
 	if err != nil {
 		return fmt.Errorf("error getting desktop path: %v", err)
 	}
 
 	domjudgePath := filepath.Join(desktopPath, "DOMJudge")
-	
+
 	// Check if folder exists
 	if _, err := os.Stat(domjudgePath); os.IsNotExist(err) {
 		// Folder doesn't exist, nothing to do
 		return nil
 	}
 
+	// A frozen folder cannot be removed until it is writable again
+	if err := UnfreezeFolder(domjudgePath); err != nil {
+		*warnings = append(*warnings, fmt.Sprintf("failed to unfreeze DOMJudge folder: %v", err))
+	}
+
 	// Remove the folder and all its contents
 	err = os.RemoveAll(domjudgePath)
 	if err != nil {
@@ -343,4 +370,4 @@ func closeBrowser() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,150 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileEntry describes a single file captured in a manifest.
+type FileEntry struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Modified time.Time `json:"modified"`
+}
+
+// Manifest is the final state of a workspace at pencils-down.
+type Manifest struct {
+	ClientID  string      `json:"clientId"`
+	Session   string      `json:"session,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Files     []FileEntry `json:"files"`
+}
+
+// Receipt is a manifest signed with the client's ed25519 key. The manifest is
+// kept as raw JSON so the signature covers exactly the bytes that were sent.
+type Receipt struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	PublicKey []byte          `json:"publicKey"`
+	Signature []byte          `json:"signature"`
+}
+
+// BuildManifest hashes every regular file below root.
+func BuildManifest(root string) ([]FileEntry, error) {
+	var files []FileEntry
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		sum, err := HashFile(path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, FileEntry{
+			Path:     filepath.ToSlash(relPath),
+			Size:     info.Size(),
+			SHA256:   sum,
+			Modified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// HashFile returns the hex-encoded SHA-256 of a file.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Sign serializes and signs a manifest.
+func Sign(manifest Manifest, key ed25519.PrivateKey) (Receipt, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	return Receipt{
+		Manifest:  data,
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, data),
+	}, nil
+}
+
+// Verify checks the receipt signature and returns the decoded manifest.
+func (r Receipt) Verify() (Manifest, error) {
+	var manifest Manifest
+
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return manifest, errors.New("invalid public key")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(r.PublicKey), r.Manifest, r.Signature) {
+		return manifest, errors.New("signature verification failed")
+	}
+	if err := json.Unmarshal(r.Manifest, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// LoadOrCreateKey reads the ed25519 signing key at path, generating and
+// saving a new one if none exists.
+func LoadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := hex.DecodeString(string(data))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid signing key in %s", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Seed())), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
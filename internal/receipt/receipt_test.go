@@ -0,0 +1,121 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestSignVerify(t *testing.T) {
+	key := testKey(t)
+	manifest := Manifest{
+		ClientID:  "linux-lab01",
+		Session:   "exam",
+		CreatedAt: time.Now(),
+		Files:     []FileEntry{{Path: "main.cpp", Size: 12, SHA256: "abc"}},
+	}
+
+	signed, err := Sign(manifest, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := signed.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.ClientID != manifest.ClientID || len(got.Files) != 1 || got.Files[0].Path != "main.cpp" {
+		t.Errorf("Verify() manifest = %+v", got)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	key := testKey(t)
+	signed, err := Sign(Manifest{ClientID: "linux-lab01", Files: []FileEntry{{Path: "main.cpp", Size: 12}}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := signed
+	tampered.Manifest = []byte(string(signed.Manifest[:len(signed.Manifest)-1]) + " ")
+	if _, err := tampered.Verify(); err == nil {
+		t.Error("tampered manifest verified")
+	}
+
+	wrongKey := signed
+	wrongKey.PublicKey = testKey(t).Public().(ed25519.PublicKey)
+	if _, err := wrongKey.Verify(); err == nil {
+		t.Error("manifest verified with the wrong key")
+	}
+
+	shortKey := signed
+	shortKey.PublicKey = []byte{1, 2, 3}
+	if _, err := shortKey.Verify(); err == nil {
+		t.Error("manifest verified with an invalid key")
+	}
+}
+
+func TestBuildManifest(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", "main.cpp"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := BuildManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+
+	if files[0].Path != "a/main.cpp" || files[0].Size != 5 {
+		t.Errorf("files[0] = %+v", files[0])
+	}
+	const helloSHA = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if files[0].SHA256 != helloSHA {
+		t.Errorf("SHA256 = %s, want %s", files[0].SHA256, helloSHA)
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "client.key")
+
+	key, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(again) {
+		t.Error("second load returned a different key")
+	}
+
+	if err := os.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOrCreateKey(path); err == nil {
+		t.Error("corrupt key file accepted")
+	}
+}
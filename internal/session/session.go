@@ -0,0 +1,202 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gradekeeper/internal/config"
+)
+
+// Phase is a step in the lifecycle of an exam session.
+type Phase string
+
+const (
+	PhaseScheduled   Phase = "scheduled"
+	PhasePrepare     Phase = "prepare"
+	PhaseOpen        Phase = "open"
+	PhaseRunning     Phase = "running"
+	PhasePencilsDown Phase = "pencils-down"
+	PhaseCollect     Phase = "collect"
+	PhaseClear       Phase = "clear"
+)
+
+// MaxArtifactSize caps the size of a single file collected from a client.
+const MaxArtifactSize = 5 * 1024 * 1024
+
+// phaseOrder lists phases in the order a session moves through them.
+var phaseOrder = []Phase{
+	PhaseScheduled,
+	PhasePrepare,
+	PhaseOpen,
+	PhaseRunning,
+	PhasePencilsDown,
+	PhaseCollect,
+	PhaseClear,
+}
+
+// phaseActions maps each phase to the client actions triggered when it is entered.
+var phaseActions = map[Phase][]string{
+	PhasePrepare:     {"setup"},
+	PhaseOpen:        {"open-vscode", "open-chrome"},
+	PhasePencilsDown: {"freeze"},
+	PhaseCollect:     {"collect"},
+	PhaseClear:       {"clear"},
+}
+
+// Phases returns all phases in lifecycle order.
+func Phases() []Phase {
+	phases := make([]Phase, len(phaseOrder))
+	copy(phases, phaseOrder)
+	return phases
+}
+
+// ParsePhase validates a phase name.
+func ParsePhase(name string) (Phase, error) {
+	for _, phase := range phaseOrder {
+		if string(phase) == name {
+			return phase, nil
+		}
+	}
+	return "", fmt.Errorf("unknown phase: %s", name)
+}
+
+// Steps returns the phases a session passes through when moving forward from
+// one phase to another, ending with the target phase.
+func Steps(from, to Phase) []Phase {
+	start, end := from.index(), to.index()
+	if start < 0 || end <= start {
+		return nil
+	}
+	return Phases()[start+1 : end+1]
+}
+
+// Actions returns the client actions triggered when the phase is entered.
+func (p Phase) Actions() []string {
+	actions := make([]string, len(phaseActions[p]))
+	copy(actions, phaseActions[p])
+	return actions
+}
+
+func (p Phase) index() int {
+	for i, phase := range phaseOrder {
+		if phase == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// ExamSession groups the configuration, clients and artifacts of a single exam.
+type ExamSession struct {
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	StartTime      time.Time              `json:"startTime"`
+	EndTime        time.Time              `json:"endTime"`
+	Clients        []string               `json:"clients"`
+	URLs           []string               `json:"urls"`
+	Workspace      []config.WorkspaceFile `json:"workspace"`
+	Phase          Phase                  `json:"phase"`
+	PhaseChangedAt time.Time              `json:"phaseChangedAt"`
+	CreatedAt      time.Time              `json:"createdAt"`
+}
+
+// New creates a scheduled session with a random ID.
+func New(name string, start, end time.Time) *ExamSession {
+	now := time.Now()
+	return &ExamSession{
+		ID:             newID(),
+		Name:           strings.TrimSpace(name),
+		StartTime:      start,
+		EndTime:        end,
+		Phase:          PhaseScheduled,
+		PhaseChangedAt: now,
+		CreatedAt:      now,
+	}
+}
+
+// Validate checks that the session has a name and a sensible time window.
+func (s *ExamSession) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("session name is required")
+	}
+	if s.StartTime.IsZero() || s.EndTime.IsZero() {
+		return errors.New("start and end times are required")
+	}
+	if !s.EndTime.After(s.StartTime) {
+		return errors.New("end time must be after start time")
+	}
+	for _, file := range s.Workspace {
+		if err := file.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeforeDeadline reports whether the phase comes before pencils-down, i.e.
+// students may still be editing.
+func (p Phase) BeforeDeadline() bool {
+	return p.index() < PhasePencilsDown.index()
+}
+
+// Active reports whether the session is still in progress.
+func (s *ExamSession) Active() bool {
+	return s.Phase != PhaseClear
+}
+
+// HasClient reports whether the client is assigned to the session.
+// An empty assignment list means every client takes part.
+func (s *ExamSession) HasClient(clientID string) bool {
+	if len(s.Clients) == 0 {
+		return true
+	}
+	for _, id := range s.Clients {
+		if id == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransition reports whether the session may move to the given phase.
+// Sessions only move forward, but may skip phases.
+func (s *ExamSession) CanTransition(to Phase) error {
+	from := s.Phase.index()
+	next := to.index()
+	if next < 0 {
+		return fmt.Errorf("unknown phase: %s", to)
+	}
+	if next <= from {
+		return fmt.Errorf("cannot move from %s back to %s", s.Phase, to)
+	}
+	return nil
+}
+
+// Transition moves the session to the given phase.
+func (s *ExamSession) Transition(to Phase) error {
+	if err := s.CanTransition(to); err != nil {
+		return err
+	}
+	s.Phase = to
+	s.PhaseChangedAt = time.Now()
+	return nil
+}
+
+// Copy returns a deep copy of the session.
+func (s *ExamSession) Copy() ExamSession {
+	cp := *s
+	cp.Clients = append([]string(nil), s.Clients...)
+	cp.URLs = append([]string(nil), s.URLs...)
+	cp.Workspace = append([]config.WorkspaceFile(nil), s.Workspace...)
+	return cp
+}
+
+func newID() string {
+	bytes := make([]byte, 4)
+	rand.Read(bytes)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(bytes)
+}
@@ -0,0 +1,123 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gradekeeper/internal/config"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Phase
+		to      Phase
+		wantErr bool
+	}{
+		{"next phase", PhaseScheduled, PhasePrepare, false},
+		{"skip phases", PhasePrepare, PhaseRunning, false},
+		{"to clear", PhaseCollect, PhaseClear, false},
+		{"same phase", PhaseRunning, PhaseRunning, true},
+		{"backwards", PhasePencilsDown, PhaseRunning, true},
+		{"unknown phase", PhasePrepare, Phase("paused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("exam", time.Now(), time.Now().Add(time.Hour))
+			s.Phase = tt.from
+
+			err := s.Transition(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transition(%s -> %s) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			}
+
+			want := tt.to
+			if tt.wantErr {
+				want = tt.from
+			}
+			if s.Phase != want {
+				t.Errorf("phase = %s, want %s", s.Phase, want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		session ExamSession
+		wantErr bool
+	}{
+		{"valid", ExamSession{Name: "exam", StartTime: start, EndTime: start.Add(time.Hour)}, false},
+		{"missing name", ExamSession{Name: "  ", StartTime: start, EndTime: start.Add(time.Hour)}, true},
+		{"missing times", ExamSession{Name: "exam"}, true},
+		{"end before start", ExamSession{Name: "exam", StartTime: start, EndTime: start.Add(-time.Hour)}, true},
+		{"escaping template", ExamSession{
+			Name: "exam", StartTime: start, EndTime: start.Add(time.Hour),
+			Workspace: []config.WorkspaceFile{{Path: "../main.cpp"}},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.session.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasClient(t *testing.T) {
+	everyone := ExamSession{}
+	if !everyone.HasClient("linux-lab01") {
+		t.Error("session without assignments should include every client")
+	}
+
+	assigned := ExamSession{Clients: []string{"linux-lab01", "linux-lab02"}}
+	if !assigned.HasClient("linux-lab02") {
+		t.Error("assigned client not found")
+	}
+	if assigned.HasClient("linux-lab03") {
+		t.Error("unassigned client reported as assigned")
+	}
+}
+
+func TestBeforeDeadline(t *testing.T) {
+	for _, phase := range []Phase{PhaseScheduled, PhasePrepare, PhaseOpen, PhaseRunning} {
+		if !phase.BeforeDeadline() {
+			t.Errorf("%s should be before the deadline", phase)
+		}
+	}
+	for _, phase := range []Phase{PhasePencilsDown, PhaseCollect, PhaseClear} {
+		if phase.BeforeDeadline() {
+			t.Errorf("%s should not be before the deadline", phase)
+		}
+	}
+}
+
+func TestSteps(t *testing.T) {
+	tests := []struct {
+		name string
+		from Phase
+		to   Phase
+		want []Phase
+	}{
+		{"scheduled to running", PhaseScheduled, PhaseRunning, []Phase{PhasePrepare, PhaseOpen, PhaseRunning}},
+		{"next phase", PhaseOpen, PhaseRunning, []Phase{PhaseRunning}},
+		{"same phase", PhaseRunning, PhaseRunning, nil},
+		{"backwards", PhaseCollect, PhaseRunning, nil},
+		{"unknown phase", Phase("paused"), PhaseRunning, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Steps(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Steps(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
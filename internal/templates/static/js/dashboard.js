@@ -9,6 +9,10 @@ let currentConfig = { urls: [] };
 let configStatusTimeout = null;
 let codeEditor = null;
 let currentClientForFiles = null;
+let currentSession = null;
+let sessionPhases = [];
+let allSessions = [];
+let knownClients = [];
 
 // Initialize Lucide icons after DOM is loaded
 document.addEventListener('DOMContentLoaded', function() {
@@ -29,6 +33,7 @@ function connect() {
         log('Dashboard connected to master server');
         refreshClients();
         loadConfig();
+        loadSessions();
     };
 
     ws.onmessage = function(event) {
@@ -54,9 +59,28 @@ function connect() {
                     (data.data.error ? ' (Error: ' + data.data.error + ')' : ''));
                 refreshClients();
                 break;
+            case 'receipt':
+                log('Receipt from ' + escapeHtml(data.data.clientId) + ': ' + escapeHtml(data.data.status) +
+                    ' (' + data.data.files + ' files)' + (data.data.error ? ' (Error: ' + escapeHtml(data.data.error) + ')' : ''));
+                refreshClients();
+                break;
             case 'file_data':
                 handleFileData(data.data);
                 break;
+            case 'file_event':
+                if (data.data.late) {
+                    log('<span class="text-red-400">Late edit by ' + escapeHtml(data.data.clientId) + ': ' +
+                        escapeHtml(data.data.op) + ' ' + escapeHtml(data.data.path) + '</span>');
+                    refreshClients();
+                }
+                if (document.getElementById('sessionActivity').open) {
+                    loadActivity();
+                }
+                break;
+            case 'session_update':
+                log('Session ' + escapeHtml(data.data.name) + ' is now in phase: ' + escapeHtml(data.data.phase));
+                applySessionUpdate(data.data);
+                break;
             case 'config_update':
                 applyConfigUpdate(data.data);
                 const urlCount = (data.data && Array.isArray(data.data.urls)) ? data.data.urls.length : 0;
@@ -107,6 +131,8 @@ function refreshClients(animateNewClient = false) {
         .then(clients => {
             const container = document.getElementById('clients');
             const currentClientIds = new Set(clients.map(c => c.id));
+            knownClients = clients;
+            renderTimelineOptions();
 
             container.innerHTML = clients.map(client => {
                 const isConnected = client.status === 'connected';
@@ -129,6 +155,26 @@ function refreshClients(animateNewClient = false) {
                         ${client.actionError ? `<div class="text-red-500 text-xs">Error: ${client.actionError}</div>` : ''}
                     </div>` : '';
 
+                const receiptStatus = client.receipt ?
+                    `<div class="mt-2 text-xs ${client.receipt === 'verified' ? 'text-green-600' : 'text-red-600'} flex items-center gap-1">
+                        <i data-lucide="${client.receipt === 'verified' ? 'shield-check' : 'shield-alert'}" class="w-3 h-3"></i>
+                        Receipt ${client.receipt} at ${new Date(client.receiptAt).toLocaleTimeString()}
+                    </div>` : '';
+
+                const lateEdits = client.lateEdits > 0 && currentSession && client.lateEditSession === currentSession.id ?
+                    `<div class="mt-2 text-xs text-red-600 flex items-center gap-2">
+                        <i data-lucide="clock-alert" class="w-3 h-3"></i>
+                        ${client.lateEdits} edit${client.lateEdits === 1 ? '' : 's'} after the end time
+                        <button onclick="showTimeline('${client.id}')" class="underline">Timeline</button>
+                    </div>` : '';
+
+                const keyWarning = client.keyMismatch ?
+                    `<div class="mt-2 text-xs text-red-600 flex items-center gap-2">
+                        <i data-lucide="key-round" class="w-3 h-3"></i>
+                        Signing key changed since registration
+                        <button onclick="resetClientKey('${client.id}')" class="underline">Reset key</button>
+                    </div>` : '';
+
                 const actionButtons = isConnected ? `
                     <div class="flex gap-2 mt-4">
                         <button onclick="sendCommand('${client.id}', 'setup')" class="bg-primary hover:bg-blue-700 text-white px-3 py-2 rounded-md text-sm flex items-center gap-2">
@@ -139,6 +185,14 @@ function refreshClients(animateNewClient = false) {
                             <i data-lucide="trash-2" class="w-4 h-4"></i>
                             Clear
                         </button>
+                        <button onclick="freezeClient('${client.id}')" class="bg-white border border-gray-300 text-gray-700 hover:bg-gray-50 px-3 py-2 rounded-md text-sm flex items-center gap-2" title="Lock the workspace and collect a receipt">
+                            <i data-lucide="lock" class="w-4 h-4"></i>
+                            Freeze
+                        </button>
+                        <button onclick="unfreezeClient('${client.id}')" class="bg-white border border-gray-300 text-gray-700 hover:bg-gray-50 px-3 py-2 rounded-md text-sm flex items-center gap-2" title="Restore write access (time extension)">
+                            <i data-lucide="lock-open" class="w-4 h-4"></i>
+                            Unfreeze
+                        </button>
                         <button onclick="showFileViewer('${client.id}', '${client.name}')" class="bg-gray-700 hover:bg-gray-800 text-white px-3 py-2 rounded-md text-sm flex items-center gap-2">
                             <i data-lucide="folder-open" class="w-4 h-4"></i>
                             Files
@@ -190,6 +244,9 @@ function refreshClients(animateNewClient = false) {
                                 </div>
                             </div>
                             ${actionStatus}
+                            ${receiptStatus}
+                            ${lateEdits}
+                            ${keyWarning}
                             ${actionButtons}
                         </div>
                     </div>
@@ -218,6 +275,34 @@ function sendCommand(clientId, action) {
     log('Sent command: ' + action + ' to ' + clientId);
 }
 
+function freezeClient(clientId) {
+    if (confirm('Close the editors of ' + clientId + ' and make its workspace read-only?')) {
+        sendCommand(clientId, 'freeze');
+    }
+}
+
+function unfreezeClient(clientId) {
+    if (confirm('Restore write access to the workspace of ' + clientId + '?')) {
+        sendCommand(clientId, 'unfreeze');
+    }
+}
+
+function resetClientKey(clientId) {
+    if (!confirm('Forget the registered signing key of ' + clientId + '? The key it presents on its next connection will be registered instead.')) {
+        return;
+    }
+
+    fetch('/api/clients/key?clientId=' + encodeURIComponent(clientId), { method: 'DELETE' })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('Failed to reset key');
+            }
+            log('Signing key reset for ' + escapeHtml(clientId));
+            refreshClients();
+        })
+        .catch(error => log(escapeHtml(error.message)));
+}
+
 function refreshClientsWithAnimation() {
     refreshClients(true);
 }
@@ -520,6 +605,356 @@ function setConfigStatus(message, state = 'info') {
     }
 }
 
+function loadSessions() {
+    fetch('/api/sessions')
+        .then(response => response.json())
+        .then(result => {
+            sessionPhases = result.phases || [];
+            currentSession = result.active || null;
+            allSessions = result.sessions || [];
+            renderSession();
+            renderSessionOptions();
+        })
+        .catch(error => {
+            console.error('Failed to load sessions', error);
+        });
+}
+
+function applySessionUpdate(session) {
+    if (!session) return;
+    currentSession = session.phase === 'clear' ? null : session;
+    allSessions = [session].concat(allSessions.filter(s => s.id !== session.id));
+    renderSession();
+    renderSessionOptions();
+}
+
+function renderSessionOptions() {
+    const select = document.getElementById('sessionRecordsSelect');
+    if (!select) return;
+
+    const selected = select.value;
+    select.innerHTML = allSessions.map(s =>
+        `<option value="${escapeHtml(s.id)}">${escapeHtml(s.name)} (${new Date(s.startTime).toLocaleDateString()})</option>`
+    ).join('');
+    if (selected && allSessions.some(s => s.id === selected)) {
+        select.value = selected;
+    }
+}
+
+function loadSessionRecords() {
+    const select = document.getElementById('sessionRecordsSelect');
+    const container = document.getElementById('sessionRecords');
+    if (!select || !container) return;
+
+    const id = select.value;
+    if (!id) {
+        container.innerHTML = '<p class="text-gray-500">No sessions yet.</p>';
+        return;
+    }
+
+    fetch('/api/sessions/records?id=' + encodeURIComponent(id))
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text || 'Failed to load records'); });
+            }
+            return response.json();
+        })
+        .then(records => renderSessionRecords(id, records))
+        .catch(error => {
+            container.innerHTML = '<p class="text-red-600">' + escapeHtml(error.message) + '</p>';
+        });
+}
+
+function activitySessionId() {
+    const select = document.getElementById('sessionRecordsSelect');
+    if (select && select.value) return select.value;
+    return currentSession ? currentSession.id : '';
+}
+
+function renderTimelineOptions() {
+    const select = document.getElementById('timelineClient');
+    if (!select) return;
+
+    const selected = select.value;
+    select.innerHTML = knownClients.map(c =>
+        `<option value="${escapeHtml(c.id)}">${escapeHtml(c.name)} (${escapeHtml(c.id)})</option>`
+    ).join('');
+    if (selected && knownClients.some(c => c.id === selected)) {
+        select.value = selected;
+    }
+}
+
+function showTimeline(clientId) {
+    const details = document.getElementById('sessionActivity');
+    const select = document.getElementById('timelineClient');
+    if (!details || !select) return;
+
+    select.value = clientId;
+    details.open = true;
+    loadActivity();
+    details.scrollIntoView({ behavior: 'smooth' });
+}
+
+function loadActivity() {
+    loadLateEdits();
+    loadTimeline();
+}
+
+function loadLateEdits() {
+    const container = document.getElementById('lateEdits');
+    const id = activitySessionId();
+    if (!container) return;
+    if (!id) {
+        container.innerHTML = '<p class="text-gray-500">No session selected.</p>';
+        return;
+    }
+
+    fetch('/api/sessions/late?id=' + encodeURIComponent(id))
+        .then(response => response.json())
+        .then(events => {
+            container.innerHTML = events.length === 0 ?
+                '<p class="text-gray-500">No changes after the end time.</p>' :
+                renderTimelineTable(events, true);
+        })
+        .catch(error => {
+            container.innerHTML = '<p class="text-red-600">' + escapeHtml(error.message) + '</p>';
+        });
+}
+
+function loadTimeline() {
+    const container = document.getElementById('clientTimeline');
+    const select = document.getElementById('timelineClient');
+    const id = activitySessionId();
+    if (!container || !select) return;
+    if (!id || !select.value) {
+        container.innerHTML = '';
+        return;
+    }
+
+    fetch('/api/sessions/timeline?id=' + encodeURIComponent(id) + '&clientId=' + encodeURIComponent(select.value))
+        .then(response => response.json())
+        .then(events => {
+            container.innerHTML = events.length === 0 ?
+                '<p class="text-gray-500">No workspace activity recorded.</p>' :
+                renderTimelineTable(events, false);
+        })
+        .catch(error => {
+            container.innerHTML = '<p class="text-red-600">' + escapeHtml(error.message) + '</p>';
+        });
+}
+
+function renderTimelineTable(events, showClient) {
+    const rows = events.map(e => `
+        <tr class="${e.late ? 'bg-red-50 text-red-700' : ''}">
+            <td class="pr-3 whitespace-nowrap">${new Date(e.time).toLocaleTimeString()}</td>
+            ${showClient ? `<td class="pr-3">${escapeHtml(e.clientId)}</td>` : ''}
+            <td class="pr-3">${escapeHtml(e.op)}</td>
+            <td class="pr-3 font-mono">${escapeHtml(e.path)}</td>
+            <td class="pr-3 text-right">${e.op === 'remove' ? '' : formatFileSize(e.size)}</td>
+            <td class="font-mono text-xs text-gray-400" title="${escapeHtml(e.sha256)}">${escapeHtml((e.sha256 || '').slice(0, 12))}</td>
+        </tr>`).join('');
+
+    return `
+        <div class="max-h-64 overflow-auto">
+            <table class="w-full text-left">
+                <thead class="text-xs text-gray-500">
+                    <tr><th>Time</th>${showClient ? '<th>Client</th>' : ''}<th>Change</th><th>File</th><th class="text-right">Size</th><th>SHA-256</th></tr>
+                </thead>
+                <tbody>${rows}</tbody>
+            </table>
+        </div>`;
+}
+
+function sessionFileLink(id, path, label) {
+    const href = '/api/sessions/file?id=' + encodeURIComponent(id) + '&path=' + encodeURIComponent(path);
+    return `<a href="${escapeHtml(href)}" target="_blank" class="text-primary hover:underline font-mono">${escapeHtml(label)}</a>`;
+}
+
+function renderSessionRecords(id, records) {
+    const container = document.getElementById('sessionRecords');
+    if (!container) return;
+
+    const artifacts = records.artifacts.length === 0 ? '<p class="text-gray-500">No artifacts collected.</p>' :
+        '<ul class="space-y-1">' + records.artifacts.map(a =>
+            `<li><span class="text-gray-500">${escapeHtml(a.client)}:</span> ${sessionFileLink(id, a.path, a.path.split('/').slice(2).join('/'))} <span class="text-xs text-gray-400">${formatFileSize(a.size)}</span></li>`
+        ).join('') + '</ul>';
+
+    const receipts = records.receipts.length === 0 ? '<p class="text-gray-500">No receipts received.</p>' :
+        '<ul class="space-y-1">' + records.receipts.map(r =>
+            `<li>${sessionFileLink(id, r.path, r.path.split('/').pop())} ${r.rejected ? '<span class="text-xs text-red-600">rejected</span>' : ''}</li>`
+        ).join('') + '</ul>';
+
+    const events = records.events.length === 0 ? 'No events.' : records.events.map(escapeHtml).join('\n');
+
+    container.innerHTML = `
+        <h4 class="font-semibold text-gray-700 mb-1">Artifacts (${records.artifacts.length})</h4>
+        ${artifacts}
+        <h4 class="font-semibold text-gray-700 mt-3 mb-1">Receipts (${records.receipts.length})</h4>
+        ${receipts}
+        <h4 class="font-semibold text-gray-700 mt-3 mb-1">Event log</h4>
+        <pre class="bg-gray-900 text-gray-100 text-xs rounded-md p-3 max-h-64 overflow-auto">${events}</pre>
+    `;
+}
+
+function renderSession() {
+    const summary = document.getElementById('sessionSummary');
+    const phases = document.getElementById('sessionPhases');
+    if (!summary || !phases) return;
+
+    if (!currentSession) {
+        summary.innerHTML = '<p class="text-sm text-gray-500">No active session.</p>';
+        phases.innerHTML = '';
+        updateCountdown();
+        return;
+    }
+
+    const clients = currentSession.clients && currentSession.clients.length > 0 ?
+        currentSession.clients.map(escapeHtml).join(', ') : 'All clients';
+
+    summary.innerHTML = `
+        <div class="flex items-center gap-3">
+            <h3 class="text-lg font-semibold text-gray-800">${escapeHtml(currentSession.name)}</h3>
+            <span class="text-xs bg-blue-100 text-blue-800 px-2 py-1 rounded-full">${escapeHtml(currentSession.phase)}</span>
+        </div>
+        <p class="text-sm text-gray-500">${new Date(currentSession.startTime).toLocaleString()} - ${new Date(currentSession.endTime).toLocaleString()}</p>
+        <p class="text-xs text-gray-400">Clients: ${clients}</p>
+    `;
+
+    const currentIndex = sessionPhases.indexOf(currentSession.phase);
+    phases.innerHTML = sessionPhases.filter(phase => phase !== 'scheduled').map(phase => {
+        const index = sessionPhases.indexOf(phase);
+        const isCurrent = index === currentIndex;
+        const isDone = index < currentIndex;
+        const classes = isCurrent ? 'bg-primary text-white' :
+            (isDone ? 'bg-gray-100 text-gray-400 cursor-not-allowed' : 'bg-white border border-gray-300 text-gray-700 hover:bg-gray-50');
+        const disabled = isCurrent || isDone ? 'disabled' : '';
+        return `<button ${disabled} onclick="setSessionPhase('${phase}')" class="${classes} px-4 py-2 rounded-md text-sm font-medium">${phase}</button>`;
+    }).join('');
+
+    updateCountdown();
+}
+
+function updateCountdown() {
+    const label = document.getElementById('sessionCountdownLabel');
+    const value = document.getElementById('sessionCountdown');
+    if (!label || !value) return;
+
+    if (!currentSession) {
+        label.textContent = '';
+        value.textContent = '';
+        return;
+    }
+
+    const now = Date.now();
+    const start = new Date(currentSession.startTime).getTime();
+    const end = new Date(currentSession.endTime).getTime();
+
+    if (now < start) {
+        label.textContent = 'Starts in';
+        value.textContent = formatDuration(start - now);
+    } else if (now < end) {
+        label.textContent = 'Time remaining';
+        value.textContent = formatDuration(end - now);
+    } else {
+        label.textContent = 'Ended';
+        value.textContent = '00:00:00';
+    }
+}
+
+function formatDuration(ms) {
+    const totalSeconds = Math.max(0, Math.floor(ms / 1000));
+    const hours = Math.floor(totalSeconds / 3600);
+    const minutes = Math.floor((totalSeconds % 3600) / 60);
+    const seconds = totalSeconds % 60;
+    return [hours, minutes, seconds].map(n => String(n).padStart(2, '0')).join(':');
+}
+
+function createSession() {
+    const name = document.getElementById('sessionName').value.trim();
+    const start = document.getElementById('sessionStart').value;
+    const end = document.getElementById('sessionEnd').value;
+    const clients = document.getElementById('sessionClients').value
+        .split(',')
+        .map(id => id.trim())
+        .filter(id => id.length > 0);
+    const urls = document.getElementById('sessionUrls').value
+        .split('\n')
+        .map(url => url.trim())
+        .filter(url => url.length > 0);
+    const templateName = document.getElementById('sessionTemplateName').value.trim();
+    const templateContent = document.getElementById('sessionTemplateContent').value;
+
+    if (!name || !start || !end) {
+        setSessionStatus('Name, start time and end time are required', 'error');
+        return;
+    }
+
+    const body = {
+        name: name,
+        startTime: new Date(start).toISOString(),
+        endTime: new Date(end).toISOString(),
+        clients: clients,
+        urls: urls,
+        workspace: templateName ? [{ path: templateName, content: templateContent }] : []
+    };
+
+    fetch('/api/sessions', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body)
+    })
+    .then(response => {
+        if (!response.ok) {
+            return response.text().then(text => { throw new Error(text || 'Failed to create session'); });
+        }
+        return response.json();
+    })
+    .then(session => {
+        setSessionStatus('Session created', 'success');
+        applySessionUpdate(session);
+    })
+    .catch(error => {
+        setSessionStatus(error.message || 'Failed to create session', 'error');
+    });
+}
+
+function setSessionPhase(phase) {
+    if (!currentSession) return;
+    if (!confirm('Move session "' + currentSession.name + '" to phase "' + phase + '"?')) {
+        return;
+    }
+
+    fetch('/api/sessions/phase', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ id: currentSession.id, phase: phase })
+    })
+    .then(response => {
+        if (!response.ok) {
+            return response.text().then(text => { throw new Error(text || 'Failed to change phase'); });
+        }
+        setSessionStatus('Phase changed to ' + phase, 'success');
+    })
+    .catch(error => {
+        setSessionStatus(error.message || 'Failed to change phase', 'error');
+    });
+}
+
+function setSessionStatus(message, state = 'info') {
+    const statusEl = document.getElementById('sessionStatus');
+    if (!statusEl) return;
+
+    let colorClass = 'text-gray-600';
+    if (state === 'success') {
+        colorClass = 'text-green-600';
+    } else if (state === 'error') {
+        colorClass = 'text-red-600';
+    }
+
+    statusEl.className = 'text-sm mt-3 ' + colorClass;
+    statusEl.textContent = message;
+}
+
 function escapeHtml(str) {
     if (!str) return '';
     return str.replace(/&/g, '&amp;')
@@ -538,5 +973,7 @@ function log(message) {
 
 renderUrlInputs();
 loadConfig();
+loadSessions();
 connect();
 setInterval(refreshClients, 5000);
+setInterval(updateCountdown, 1000);
@@ -0,0 +1,165 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gradekeeper/internal/receipt"
+)
+
+// Op is the kind of change observed on a file.
+type Op string
+
+const (
+	OpCreate Op = "create"
+	OpWrite  Op = "write"
+	OpRemove Op = "remove"
+)
+
+// Event describes a change to a file in the watched folder. Time is when the
+// change was observed; Modified is the file's own modification time, which
+// students can set freely.
+type Event struct {
+	Path     string    `json:"path"`
+	Op       Op        `json:"op"`
+	Time     time.Time `json:"time"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256,omitempty"`
+	Modified time.Time `json:"modified"`
+}
+
+type fileState struct {
+	size     int64
+	modified time.Time
+}
+
+// Watcher detects changes in a folder by polling it. Polling needs no
+// OS-specific APIs and keeps working when the folder is deleted and created
+// again, which happens on every setup and clear.
+type Watcher struct {
+	root  string
+	files map[string]fileState
+}
+
+// New creates a watcher for root. The folder does not need to exist yet.
+func New(root string) *Watcher {
+	return &Watcher{root: root}
+}
+
+// Root returns the watched folder.
+func (w *Watcher) Root() string {
+	return w.root
+}
+
+// Poll scans the folder and returns the changes since the previous call.
+// The first call only records the current state.
+func (w *Watcher) Poll() ([]Event, error) {
+	current, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := w.files
+	w.files = current
+	if previous == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var events []Event
+
+	for path, state := range current {
+		old, existed := previous[path]
+		if existed && old == state {
+			continue
+		}
+
+		op := OpWrite
+		if !existed {
+			op = OpCreate
+		}
+
+		event := Event{
+			Path:     path,
+			Op:       op,
+			Time:     now,
+			Size:     state.size,
+			Modified: state.modified,
+		}
+		// The file may change again or disappear while it is hashed; the
+		// event is still worth reporting without a hash
+		if sum, err := receipt.HashFile(filepath.Join(w.root, filepath.FromSlash(path))); err == nil {
+			event.SHA256 = sum
+		}
+		events = append(events, event)
+	}
+
+	for path, state := range previous {
+		if _, exists := current[path]; !exists {
+			events = append(events, Event{
+				Path:     path,
+				Op:       OpRemove,
+				Time:     now,
+				Modified: state.modified,
+			})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+	return events, nil
+}
+
+// Run polls the folder every interval until stop is closed, passing each
+// change to handle. Scan errors are passed to onError, if set.
+func (w *Watcher) Run(interval time.Duration, stop <-chan struct{}, handle func(Event), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := w.Poll()
+		if err != nil && onError != nil {
+			onError(err)
+		}
+		for _, event := range events {
+			handle(event)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (w *Watcher) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+
+	err := filepath.Walk(w.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = fileState{size: info.Size(), modified: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
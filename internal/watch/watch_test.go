@@ -0,0 +1,126 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPoll(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "DOMJudge")
+	w := New(root)
+
+	// The folder does not exist yet
+	if events, err := w.Poll(); err != nil || len(events) != 0 {
+		t.Fatalf("first Poll() = %v, %v; want no events", events, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mainPath := filepath.Join(root, "main.cpp")
+	if err := os.WriteFile(mainPath, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", "a.py"), []byte("print()"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := w.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %v", len(events), events)
+	}
+	if events[0].Path != "a/a.py" || events[0].Op != OpCreate {
+		t.Errorf("events[0] = %+v, want create of a/a.py", events[0])
+	}
+	if events[1].Path != "main.cpp" || events[1].Size != 5 ||
+		events[1].SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("events[1] = %+v, want create of main.cpp with size and hash", events[1])
+	}
+
+	if events, _ := w.Poll(); len(events) != 0 {
+		t.Errorf("Poll() without changes = %v, want none", events)
+	}
+
+	// Same size, later modification time
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(mainPath, []byte("world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(mainPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "a", "a.py")); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err = w.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %v", len(events), events)
+	}
+	if events[0].Path != "a/a.py" || events[0].Op != OpRemove {
+		t.Errorf("events[0] = %+v, want remove of a/a.py", events[0])
+	}
+	if events[1].Path != "main.cpp" || events[1].Op != OpWrite || !events[1].Modified.Equal(later) {
+		t.Errorf("events[1] = %+v, want write of main.cpp", events[1])
+	}
+
+	// Removing the whole folder reports every file
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+	events, err = w.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Op != OpRemove {
+		t.Errorf("Poll() after removal = %v, want remove of main.cpp", events)
+	}
+}
+
+func TestRunStops(t *testing.T) {
+	root := t.TempDir()
+	w := New(root)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	got := make(chan Event, 1)
+
+	go func() {
+		w.Run(10*time.Millisecond, stop, func(e Event) {
+			select {
+			case got <- e:
+			default:
+			}
+		}, nil)
+		close(done)
+	}()
+
+	// Wait for the baseline scan before creating the file
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(root, "x.c"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-got:
+		if e.Path != "x.c" || e.Op != OpCreate {
+			t.Errorf("event = %+v, want create of x.c", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop")
+	}
+}